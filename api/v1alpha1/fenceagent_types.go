@@ -0,0 +1,129 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FenceAgentCatalogLabel marks the FenceAgent objects which are managed by the operator
+	FenceAgentCatalogLabel = "fence-agents-remediation.medik8s.io/catalog"
+)
+
+// FenceAgentParameter describes a parameter of the fence agent, as reported by its metadata
+type FenceAgentParameter struct {
+	// Name is the parameter name, which is also its name when the parameters are passed over stdin
+	Name string `json:"name"`
+
+	// Options are the command line options of the parameter, e.g. "--username" and "-l"
+	// +optional
+	Options []string `json:"options,omitempty"`
+
+	// Type is the parameter type, e.g. "string", "boolean", "integer", "second" or "select"
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Required is true if the fence agent can't run without the parameter
+	// +optional
+	Required bool `json:"required,omitempty"`
+
+	// Default is the parameter default value
+	// +optional
+	Default string `json:"default,omitempty"`
+
+	// AllowedValues are the values of a "select" parameter
+	// +optional
+	AllowedValues []string `json:"allowedValues,omitempty"`
+
+	// Deprecated is true if the parameter was replaced by another parameter
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Obsoletes is the name of the deprecated parameter which this parameter replaces
+	// +optional
+	Obsoletes string `json:"obsoletes,omitempty"`
+
+	// Description is the parameter description
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// FenceAgentStatus defines the fence agent, as it is installed in the operator image
+type FenceAgentStatus struct {
+	// Agent is the name of the fence agent binary, which is used as the agent of FenceAgentsRemediation
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Agent string `json:"agent,omitempty"`
+
+	// Description is the fence agent description
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Description string `json:"description,omitempty"`
+
+	// Actions are the actions which the fence agent supports, e.g. "on", "off", "reboot" and "status"
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Actions []string `json:"actions,omitempty"`
+
+	// Parameters are the parameters which the fence agent accepts
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Parameters []FenceAgentParameter `json:"parameters,omitempty"`
+
+	// LastUpdateTime is the last time the fence agent metadata was read
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=fa
+// +kubebuilder:printcolumn:name="Agent",type="string",JSONPath=".status.agent"
+// +kubebuilder:printcolumn:name="Description",type="string",JSONPath=".status.description"
+
+// FenceAgent is a read-only catalog entry of a fence agent which is installed in the operator image.
+// The FenceAgent objects are created by the operator at startup, and they shouldn't be modified by users.
+// +operator-sdk:csv:customresourcedefinitions:resources={{"FenceAgent","v1alpha1","fenceagents"}}
+type FenceAgent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status FenceAgentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FenceAgentList contains a list of FenceAgent
+type FenceAgentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FenceAgent `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FenceAgent{}, &FenceAgentList{})
+}
+
+// FenceAgentName returns the FenceAgent object name of the agent, since underscores aren't allowed in object names
+func FenceAgentName(agent string) string {
+	return strings.ReplaceAll(agent, "_", "-")
+}
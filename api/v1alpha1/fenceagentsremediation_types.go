@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,6 +29,9 @@ const (
 	FARFinalizer string = "fence-agents-remediation.medik8s.io/far-finalizer"
 	// Taints
 	FARNoExecuteTaintKey = "medik8s.io/fence-agents-remediation"
+	// AllowQuorumLossAnnotation is an annotation which allows fencing a control-plane node even though it would break the etcd quorum,
+	// when it is set to "true" on the FenceAgentsRemediation CR
+	AllowQuorumLossAnnotation = "fence-agents-remediation.medik8s.io/allow-quorum-loss"
 )
 
 // ConditionsChangeReason represents the reason of updating the some or all the conditions
@@ -47,11 +51,28 @@ const (
 
 	ResourceDeletionRemediationStrategy  = RemediationStrategyType("ResourceDeletion")
 	OutOfServiceTaintRemediationStrategy = RemediationStrategyType("OutOfServiceTaint")
+	AutomaticRemediationStrategy         = RemediationStrategyType("Automatic")
+
+	RebootFencingMode    = FencingModeType("Reboot")
+	OffThenOnFencingMode = FencingModeType("OffThenOn")
+	OffOnlyFencingMode   = FencingModeType("OffOnly")
+
+	SecretsOverStdinParametersTransport = ParametersTransportType("SecretsOverStdin")
+	StdinParametersTransport            = ParametersTransportType("Stdin")
+	CommandLineParametersTransport      = ParametersTransportType("CommandLine")
+
+	// BareMetalHostParametersSource derives the BMC address and credentials of the node from its Metal3 BareMetalHost
+	BareMetalHostParametersSource = ParametersSourceType("BareMetalHost")
+	// ProviderIDParametersSource derives the cloud instance of the node, and its location, from the provider ID of the node
+	ProviderIDParametersSource = ParametersSourceType("ProviderID")
 )
 
 type ParameterName string
 type NodeName string
 type RemediationStrategyType string
+type FencingModeType string
+type ParametersTransportType string
+type ParametersSourceType string
 
 // FenceAgentsRemediationSpec defines the desired state of FenceAgentsRemediation
 type FenceAgentsRemediationSpec struct {
@@ -59,7 +80,7 @@ type FenceAgentsRemediationSpec struct {
 	// It should have a fence_ prefix.
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +kubebuilder:validation:Type=string
-	// +kubebuilder:validation:Pattern=fence_.+
+	// +kubebuilder:validation:Pattern=`^fence_[A-Za-z0-9_]+$`
 	Agent string `json:"agent"`
 
 	// RetryCount is the number of times the fencing agent will be executed
@@ -89,16 +110,51 @@ type FenceAgentsRemediationSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	NodeParameters map[ParameterName]map[NodeName]string `json:"nodeparameters,omitempty"`
 
+	// NodeGroups are named parameter sets of groups of nodes, e.g. racks of identical nodes, which are selected by node labels.
+	// Their parameters override the shared parameters, while the node parameters override them.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	NodeGroups []NodeGroup `json:"nodeGroups,omitempty"`
+
+	// ParametersSource derives node parameters of the fence agent from other resources of the cluster, e.g. the BMC address and credentials
+	// from the node's Metal3 BareMetalHost, or the cloud instance from the node's provider ID. The derived parameters are overridden by the
+	// parameters and the Secrets params.
+	// +optional
+	// +kubebuilder:validation:Enum=BareMetalHost;ProviderID
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	ParametersSource ParametersSourceType `json:"parametersSource,omitempty"`
+
 	// RemediationStrategy is the remediation method for unhealthy nodes.
-	// Currently, it could be either "OutOfServiceTaint" or "ResourceDeletion".
+	// Currently, it could be either "OutOfServiceTaint", "ResourceDeletion" or "Automatic".
 	// ResourceDeletion will iterate over all pods related to the unhealthy node and delete them.
 	// OutOfServiceTaint will add the out-of-service taint which is a new well-known taint "node.kubernetes.io/out-of-service"
 	// that enables automatic deletion of pv-attached pods on failed nodes, "out-of-service" taint is only supported on clusters with k8s version 1.26+ or OCP/OKD version 4.13+.
+	// Automatic will use OutOfServiceTaint when the out-of-service taint is supported and the node has attached volumes, and ResourceDeletion otherwise.
 	// +kubebuilder:default:="ResourceDeletion"
-	// +kubebuilder:validation:Enum=ResourceDeletion;OutOfServiceTaint
+	// +kubebuilder:validation:Enum=ResourceDeletion;OutOfServiceTaint;Automatic
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	RemediationStrategy RemediationStrategyType `json:"remediationStrategy,omitempty"`
 
+	// FencingMode is the sequence of power actions which fences the node.
+	// Currently, it could be either "Reboot", "OffThenOn" or "OffOnly".
+	// Reboot reboots the node with a single fence agent action.
+	// OffThenOn powers off the node, waits until its workloads have been removed, and then powers on the node.
+	// OffOnly powers off the node, and keeps it powered off until the CR is deleted, and then it powers on the node.
+	// +kubebuilder:default:="Reboot"
+	// +kubebuilder:validation:Enum=Reboot;OffThenOn;OffOnly
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	FencingMode FencingModeType `json:"fencingMode,omitempty"`
+
+	// ParametersTransport is the way the parameters are passed to the fence agent.
+	// Currently, it could be either "SecretsOverStdin", "Stdin" or "CommandLine".
+	// SecretsOverStdin passes all the parameters over stdin when any of them comes from a Secret, and on the command line otherwise.
+	// Stdin always passes all the parameters over stdin.
+	// CommandLine always passes all the parameters on the command line, where they are visible to other processes of the pod.
+	// +kubebuilder:default:="SecretsOverStdin"
+	// +kubebuilder:validation:Enum=SecretsOverStdin;Stdin;CommandLine
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	ParametersTransport ParametersTransportType `json:"parametersTransport,omitempty"`
+
 	// NodeSecretNames maps the node name to the Secret name which contains params relevant for that node.
 	// +optional
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
@@ -111,6 +167,211 @@ type FenceAgentsRemediationSpec struct {
 	// +kubebuilder:validation:Type=string
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	SharedSecretName *string `json:"sharedSecretName,omitempty"`
+
+	// ParametersFrom maps parameter names to keys of Secrets or ConfigMaps which contain their values, e.g. --password to the key pw
+	// of the Secret bmc-creds, unlike the Secrets params which pass all the keys of the Secret as params. The values of Secret keys
+	// are treated as Secret params. A parameter can't be defined both by a reference and by another parameter or Secret params.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	ParametersFrom map[ParameterName]ParameterValueSource `json:"parametersFrom,omitempty"`
+
+	// Verification enables a power status verification after the fence agent action has succeeded.
+	// The fence agent is executed again with the status action until it reports the expected power state,
+	// and the remediation doesn't continue until the power state has been verified.
+	// When it is not set, a successful fence agent action is trusted without verification.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Verification *FenceAgentVerification `json:"verification,omitempty"`
+
+	// RebootVerification enables a verification that the node has actually rebooted after it was fenced, before the remediation succeeds.
+	// The node has rebooted when its boot ID differs from the boot ID which was recorded before fencing, or when it has become ready after it was fenced.
+	// It can't be used with the OffOnly fencing mode, since the node isn't powered on again.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	RebootVerification *NodeRebootVerification `json:"rebootVerification,omitempty"`
+
+	// FallbackLevels is an ordered list of fencing levels which are tried one after another when the fence agent of the spec has failed.
+	// The fence agent of the spec is fencing level 1, and the fallback levels are fencing levels 2, 3, etc.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	FallbackLevels []FencingLevel `json:"fallbackLevels,omitempty"`
+
+	// Devices are the fencing devices of the fence agent of the spec, e.g. the two PDUs of a node with redundant power supplies.
+	// All the devices are powered off, and only then all of them are powered on, and a failure of any device fails the fence agent.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Devices []FencingDevice `json:"devices,omitempty"`
+
+	// PodSelection defines which pods of the fenced node are deleted by the ResourceDeletion strategy.
+	// When it is not set, all the pods of the node are deleted.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	PodSelection *PodSelection `json:"podSelection,omitempty"`
+}
+
+// PodSelection defines which pods of the fenced node are deleted by the ResourceDeletion strategy, and which pods are skipped
+type PodSelection struct {
+	// ExcludedNamespaces are the namespaces whose pods are skipped
+	// +optional
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+
+	// IncludeSelector selects the pods which are deleted, and the other pods are skipped. All the pods are selected when it is not set.
+	// +optional
+	IncludeSelector *metav1.LabelSelector `json:"includeSelector,omitempty"`
+
+	// ExcludeSelector selects the pods which are skipped, even if they are selected by the include selector
+	// +optional
+	ExcludeSelector *metav1.LabelSelector `json:"excludeSelector,omitempty"`
+
+	// SkipDaemonSetPods skips the pods which are owned by a DaemonSet, since the DaemonSet controller recreates them on the same node
+	// +optional
+	SkipDaemonSetPods bool `json:"skipDaemonSetPods,omitempty"`
+
+	// SkipStaticPods skips the mirror pods of the static pods, which are managed by the kubelet of the node rather than by the API server
+	// +optional
+	SkipStaticPods bool `json:"skipStaticPods,omitempty"`
+}
+
+// FencingLevel defines a fence agent, with its own parameters, Secrets, retry and timeout settings, which is used to fence the node
+type FencingLevel struct {
+	// Agent is the name of fence agent that will be used.
+	// It should have a fence_ prefix.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^fence_[A-Za-z0-9_]+$`
+	Agent string `json:"agent"`
+
+	// RetryCount is the number of times the fencing agent will be executed
+	// +kubebuilder:default:=5
+	RetryCount int `json:"retrycount,omitempty"`
+
+	// RetryInterval is the interval between each fencing agent execution
+	// +kubebuilder:default:="5s"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	RetryInterval metav1.Duration `json:"retryinterval,omitempty"`
+
+	// Timeout is the timeout for each fencing agent execution
+	// +kubebuilder:default:="60s"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// SharedParameters are parameters common to all nodes
+	// +optional
+	SharedParameters map[ParameterName]string `json:"sharedparameters,omitempty"`
+
+	// NodeParameters are passed to the fencing agent according to the node that is fenced, since they are node specific
+	// +optional
+	NodeParameters map[ParameterName]map[NodeName]string `json:"nodeparameters,omitempty"`
+
+	// NodeGroups are named parameter sets of groups of nodes, which are selected by node labels.
+	// Their parameters override the shared parameters, while the node parameters override them.
+	// +optional
+	NodeGroups []NodeGroup `json:"nodeGroups,omitempty"`
+
+	// ParametersSource derives node parameters of the fence agent from other resources of the cluster, e.g. the BMC address and credentials
+	// from the node's Metal3 BareMetalHost, or the cloud instance from the node's provider ID. The derived parameters are overridden by the
+	// parameters and the Secrets params.
+	// +optional
+	// +kubebuilder:validation:Enum=BareMetalHost;ProviderID
+	ParametersSource ParametersSourceType `json:"parametersSource,omitempty"`
+
+	// NodeSecretNames maps the node name to the Secret name which contains params relevant for that node.
+	// +optional
+	NodeSecretNames map[NodeName]string `json:"nodeSecrets,omitempty"`
+
+	// SharedSecretName is the name of the Secret which contains params needed by this fencing level in order to remediate any node.
+	// Unlike the shared Secret of the spec, it has no default value.
+	// +optional
+	SharedSecretName *string `json:"sharedSecretName,omitempty"`
+
+	// ParametersFrom maps parameter names of this fencing level to keys of Secrets or ConfigMaps which contain their values.
+	// +optional
+	ParametersFrom map[ParameterName]ParameterValueSource `json:"parametersFrom,omitempty"`
+
+	// Devices are the fencing devices of this fencing level, e.g. the two PDUs of a node with redundant power supplies.
+	// All the devices are powered off, and only then all of them are powered on, and a failure of any device fails the fencing level.
+	// +optional
+	Devices []FencingDevice `json:"devices,omitempty"`
+}
+
+// FencingDevice defines the parameters of a single fencing device, which are added to the parameters of its fencing level
+type FencingDevice struct {
+	// SharedParameters are device parameters common to all nodes, and they override the fencing level's parameters
+	// +optional
+	SharedParameters map[ParameterName]string `json:"sharedparameters,omitempty"`
+
+	// NodeParameters are device parameters specific to the node that is fenced, e.g. its outlet on the device,
+	// and they override the device's shared parameters
+	// +optional
+	NodeParameters map[ParameterName]map[NodeName]string `json:"nodeparameters,omitempty"`
+}
+
+// NodeGroup defines the parameters of a group of nodes, e.g. a rack of identical nodes which share a BMC user and a PDU address
+type NodeGroup struct {
+	// Name is the name of the node group
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// NodeSelector selects the nodes of the group by their labels. A node can't be selected by more than one group.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+
+	// Parameters are passed to the fence agent for the nodes of the group
+	// +optional
+	Parameters map[ParameterName]string `json:"parameters,omitempty"`
+
+	// SecretName is the name of the Secret which contains params of the nodes of the group.
+	// They override the params of the shared Secret, while the params of the node Secret override them.
+	// +optional
+	SecretName *string `json:"secretName,omitempty"`
+}
+
+// ParameterValueSource refers to a key of a Secret or of a ConfigMap, in the namespace of the CR, which contains the value of a parameter.
+// Exactly one of them has to be set.
+type ParameterValueSource struct {
+	// SecretKeyRef selects a key of a Secret, for sensitive values such as passwords
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// ConfigMapKeyRef selects a key of a ConfigMap, for non-sensitive values
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// FencingLevelStatus identifies a fencing level
+type FencingLevelStatus struct {
+	// Level is the fencing level number, where level 1 is the fence agent of the spec, and the fallback levels start at level 2
+	Level int `json:"level"`
+
+	// Agent is the name of the fence agent of the fencing level
+	Agent string `json:"agent"`
+}
+
+// FenceAgentVerification defines how the node's power status is verified after a successful fence agent action
+type FenceAgentVerification struct {
+	// Timeout is the time window in which the fence agent has to report the expected power status
+	// +kubebuilder:default:="120s"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// Interval is the interval between each power status check
+	// +kubebuilder:default:="10s"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// NodeRebootVerification defines how long to wait for the node to reboot after it was fenced
+type NodeRebootVerification struct {
+	// Timeout is the time window, from the successful fence agent action, in which the node has to reboot
+	// +kubebuilder:default:="10m"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Timeout metav1.Duration `json:"timeout,omitempty"`
 }
 
 // FenceAgentsRemediationStatus defines the observed state of FenceAgentsRemediation
@@ -119,7 +380,8 @@ type FenceAgentsRemediationStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// Represents the observations of a FenceAgentsRemediation's current state.
-	// Known .status.conditions.type are: "Processing", "FenceAgentActionSucceeded", and "Succeeded".
+	// Known .status.conditions.type are: "Processing", "FenceAgentActionSucceeded", "FenceAgentVerified", "NodePoweredOn", "NodeRebootVerified", "Waiting",
+	// and "Succeeded".
 	// +listType=map
 	// +listMapKey=type
 	// +optional
@@ -133,6 +395,118 @@ type FenceAgentsRemediationStatus struct {
 	// +kubebuilder:validation:Format=date-time
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// FencedBy is the fencing level which has fenced the node.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	FencedBy *FencingLevelStatus `json:"fencedBy,omitempty"`
+
+	// Attempts are the most recent fence agent command attempts, from the oldest to the newest.
+	// Only the last 10 attempts are kept.
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Attempts []FenceAgentAttempt `json:"attempts,omitempty"`
+
+	// Execution is the state of the fence agent execution which is in progress.
+	// It is persisted so that another manager can take over the execution after a manager restart or a leadership change.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Execution *FenceAgentExecution `json:"execution,omitempty"`
+
+	// PreFenceBootID is the boot ID of the node which was recorded before it was fenced, when reboot verification is enabled.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	PreFenceBootID string `json:"preFenceBootID,omitempty"`
+
+	// RemediationStrategy is the remediation strategy which removes the workloads of the fenced node,
+	// which is the chosen strategy when the spec's remediation strategy is Automatic.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	RemediationStrategy RemediationStrategyType `json:"remediationStrategy,omitempty"`
+
+	// FencingHandOffTime is the time when the manager pod, which ran on the node, was deleted so the node is fenced by a manager on another node.
+	// The manager pod is deleted again only when it still runs on the node 5 minutes after this time, e.g. when it was rescheduled to the same node.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	FencingHandOffTime *metav1.Time `json:"fencingHandOffTime,omitempty"`
+
+	// WorkloadsRemovedTime is the time when the workloads of the fenced node were removed by the remediation strategy.
+	// The workloads are removed only once, even though the remediation might keep waiting afterwards, e.g. for the node to reboot.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	WorkloadsRemovedTime *metav1.Time `json:"workloadsRemovedTime,omitempty"`
+
+	// DeletedVolumeAttachments is the number of volume attachments of the fenced node which were deleted by the ResourceDeletion strategy.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	DeletedVolumeAttachments int `json:"deletedVolumeAttachments,omitempty"`
+
+	// DeletedPods is the number of pods of the fenced node which were deleted by the ResourceDeletion strategy.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	DeletedPods int `json:"deletedPods,omitempty"`
+
+	// SkippedPods is the number of pods of the fenced node which weren't deleted by the ResourceDeletion strategy, according to the pod selection.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	SkippedPods int `json:"skippedPods,omitempty"`
+}
+
+// FenceAgentExecution identifies the fence agent attempt which is in progress, and the manager which runs it
+type FenceAgentExecution struct {
+	// Owner is the identity of the manager which runs the fence agent
+	Owner string `json:"owner"`
+
+	// Level is the number of the fencing level which is executed
+	Level int `json:"level"`
+
+	// Attempt is the number of the fence agent attempt of the fencing level, starting at 1
+	Attempt int `json:"attempt"`
+
+	// StartTime is the time the fence agent attempt was started
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// FenceAgentAttempt records a single execution of a fence agent command
+type FenceAgentAttempt struct {
+	// Agent is the name of the executed fence agent
+	Agent string `json:"agent"`
+
+	// Level is the number of the fencing level of the fence agent command
+	// +optional
+	Level int `json:"level,omitempty"`
+
+	// Attempt is the number of the fence agent attempt of the fencing level, starting at 1
+	// +optional
+	Attempt int `json:"attempt,omitempty"`
+
+	// StartTime is the time the fence agent command was started
+	StartTime metav1.Time `json:"startTime"`
+
+	// EndTime is the time the fence agent command was completed
+	EndTime metav1.Time `json:"endTime"`
+
+	// QueueWait is the time the fence agent command waited for its fencing device, which was busy with the commands of other nodes,
+	// before it was started
+	// +optional
+	QueueWait *metav1.Duration `json:"queueWait,omitempty"`
+
+	// ExitCode is the exit code of the fence agent command, if it has exited
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// TimedOut is true when the fence agent command was stopped by its timeout
+	// +optional
+	TimedOut bool `json:"timedOut,omitempty"`
+
+	// Stdout is an excerpt of the fence agent command's stdout, with sensitive parameter values redacted
+	// +optional
+	Stdout string `json:"stdout,omitempty"`
+
+	// Stderr is an excerpt of the fence agent command's stderr, with sensitive parameter values redacted
+	// +optional
+	Stderr string `json:"stderr,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -17,15 +17,26 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/medik8s/fence-agents-remediation/pkg/nodetemplate"
 	"github.com/medik8s/fence-agents-remediation/pkg/validation"
 )
 
@@ -36,9 +47,35 @@ var (
 	agentValidator = validation.NewAgentValidator()
 	// isOutOfServiceTaintSupported will be set to true in case out-of-service taint is supported (k8s 1.26 or higher)
 	isOutOfServiceTaintSupported bool
+	// secretsReader reads the Secrets whose keys are validated as fence agent parameters, the validation is skipped if it is nil
+	secretsReader client.Reader
+	// fenceAgentsReader reads the FenceAgent catalog entries which the agents and their parameters are validated against,
+	// the agents in the operator image are validated directly if it is nil or if the agent has no catalog entry yet
+	fenceAgentsReader client.Reader
+	// parametersSourceAgents are the fence agents which can use the parameters of every parameters source
+	parametersSourceAgents = map[ParametersSourceType][]string{
+		BareMetalHostParametersSource: {"fence_ipmilan", "fence_redfish"},
+		ProviderIDParametersSource:    {"fence_aws", "fence_azure_arm", "fence_gce"},
+	}
+	// parametersSourceParams are the main parameters which are derived by every parameters source, e.g. the BMC address and credentials,
+	// or the cloud instance
+	parametersSourceParams = map[ParametersSourceType][]ParameterName{
+		BareMetalHostParametersSource: {"--ip", "--username", "--password"},
+		ProviderIDParametersSource:    {"--plug"},
+	}
+)
+
+const (
+	// secretsReadTimeout is the timeout for reading the Secrets during validation
+	secretsReadTimeout = 5 * time.Second
+	// fenceAgentsReadTimeout is the timeout for reading the FenceAgent catalog entries during validation
+	fenceAgentsReadTimeout = 5 * time.Second
 )
 
 func (r *FenceAgentsRemediation) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	// the API reader doesn't start a cluster wide informer for Secrets, which the operator isn't allowed to watch
+	secretsReader = mgr.GetAPIReader()
+	fenceAgentsReader = mgr.GetClient()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -54,13 +91,14 @@ var _ webhook.Validator = &FenceAgentsRemediation{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (far *FenceAgentsRemediation) ValidateCreate() (admission.Warnings, error) {
 	webhookFARLog.Info("validate create", "name", far.Name)
-	return validateFAR(&far.Spec)
+	return validateFAR(&far.Spec, far.Namespace, true)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (far *FenceAgentsRemediation) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
 	webhookFARLog.Info("validate update", "name", far.Name)
-	return validateFAR(&far.Spec)
+	oldFAR, isFAR := old.(*FenceAgentsRemediation)
+	return validateFAR(&far.Spec, far.Namespace, !isFAR || isParametersValidationRequired(far, &far.Spec, &oldFAR.Spec))
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -69,13 +107,49 @@ func (far *FenceAgentsRemediation) ValidateDelete() (admission.Warnings, error)
 	return nil, nil
 }
 
-func validateFAR(farSpec *FenceAgentsRemediationSpec) (admission.Warnings, error) {
-	aggregated := errors.NewAggregate([]error{
-		validateAgentName(farSpec.Agent),
+// isParametersValidationRequired checks whether the fence agent parameters and the Secrets of an updated object have to be validated.
+// They are validated only when the spec has changed, since the Secrets might have been changed after the object was created, and
+// rejecting updates which don't change the spec, e.g. removing the finalizer, would leave the object stuck.
+func isParametersValidationRequired(obj metav1.Object, farSpec, oldSpec *FenceAgentsRemediationSpec) bool {
+	return obj.GetDeletionTimestamp().IsZero() && !equality.Semantic.DeepEqual(farSpec, oldSpec)
+}
+
+// validateFAR validates the spec, and the fence agent parameters and the Secrets of its fencing levels if validateParams is true
+func validateFAR(farSpec *FenceAgentsRemediationSpec, namespace string, validateParams bool) (admission.Warnings, error) {
+	errs := []error{
 		validateStrategy(farSpec.RemediationStrategy),
-	})
+		validateRebootVerification(farSpec),
+		validatePodSelection(farSpec.PodSelection),
+	}
+	warnings := admission.Warnings{}
+	specLevel := FencingLevel{
+		Agent:            farSpec.Agent,
+		SharedParameters: farSpec.SharedParameters,
+		NodeParameters:   farSpec.NodeParameters,
+		NodeGroups:       farSpec.NodeGroups,
+		ParametersSource: farSpec.ParametersSource,
+		NodeSecretNames:  farSpec.NodeSecretNames,
+		SharedSecretName: farSpec.SharedSecretName,
+		ParametersFrom:   farSpec.ParametersFrom,
+		Devices:          farSpec.Devices,
+	}
+	for _, fencingLevel := range append([]FencingLevel{specLevel}, farSpec.FallbackLevels...) {
+		errs = append(errs, validateParametersSource(fencingLevel))
+		errs = append(errs, validateParametersFrom(fencingLevel))
+		if err := validateAgentName(fencingLevel.Agent); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if validateParams {
+			levelWarnings, err := validateAgentParameters(fencingLevel, namespace)
+			warnings = append(warnings, levelWarnings...)
+			errs = append(errs, err)
+		}
+		errs = append(errs, validateNodeGroups(fencingLevel.NodeGroups))
+	}
+	aggregated := errors.NewAggregate(errs)
 
-	return admission.Warnings{}, aggregated
+	return warnings, aggregated
 }
 
 func InitOutOfServiceTaintSupportedFlag(outOfServiceTaintSupported bool) {
@@ -83,6 +157,9 @@ func InitOutOfServiceTaintSupportedFlag(outOfServiceTaintSupported bool) {
 }
 
 func validateAgentName(agent string) error {
+	if schema := getCatalogSchema(agent); schema != nil {
+		return nil
+	}
 	exists, err := agentValidator.ValidateAgentName(agent)
 	if err != nil {
 		return errors.NewAggregate([]error{
@@ -96,6 +173,319 @@ func validateAgentName(agent string) error {
 	return nil
 }
 
+// validateAgentParameters validates the fencing level's shared and node parameters, and its Secrets' keys, against the fence agent metadata
+func validateAgentParameters(fencingLevel FencingLevel, namespace string) (admission.Warnings, error) {
+	params := map[string][]string{}
+	var templateErrs []error
+	addParam := func(paramName ParameterName, paramVal string) {
+		if !nodetemplate.IsTemplate(paramVal) {
+			params[string(paramName)] = append(params[string(paramName)], paramVal)
+			return
+		}
+		// the value of a template is known only once it is resolved against the fenced node, hence only its syntax is validated
+		if _, exists := params[string(paramName)]; !exists {
+			params[string(paramName)] = nil
+		}
+		if err := nodetemplate.Validate(paramVal); err != nil {
+			templateErrs = append(templateErrs, fmt.Errorf("invalid template of parameter %s: %w", paramName, err))
+		}
+	}
+	for paramName, paramVal := range fencingLevel.SharedParameters {
+		addParam(paramName, paramVal)
+	}
+	for paramName, nodeMap := range fencingLevel.NodeParameters {
+		for _, nodeVal := range nodeMap {
+			addParam(paramName, nodeVal)
+		}
+	}
+	for _, nodeGroup := range fencingLevel.NodeGroups {
+		for paramName, paramVal := range nodeGroup.Parameters {
+			addParam(paramName, paramVal)
+		}
+	}
+	// the values of the referenced parameters are read only when the node is fenced, hence only their names are registered
+	for paramName := range fencingLevel.ParametersFrom {
+		if _, exists := params[string(paramName)]; !exists {
+			params[string(paramName)] = nil
+		}
+	}
+	// the values of the parameters source are known only once they are derived for the fenced node, hence only their names are registered
+	for _, paramName := range parametersSourceParams[fencingLevel.ParametersSource] {
+		if _, exists := params[string(paramName)]; !exists {
+			params[string(paramName)] = nil
+		}
+	}
+	for _, device := range fencingLevel.Devices {
+		for paramName, paramVal := range device.SharedParameters {
+			params[string(paramName)] = append(params[string(paramName)], paramVal)
+		}
+		for paramName, nodeMap := range device.NodeParameters {
+			for _, nodeVal := range nodeMap {
+				params[string(paramName)] = append(params[string(paramName)], nodeVal)
+			}
+		}
+	}
+
+	var warnings admission.Warnings
+	secretNames := make([]string, 0, len(fencingLevel.NodeSecretNames)+1)
+	if fencingLevel.SharedSecretName != nil {
+		secretNames = append(secretNames, *fencingLevel.SharedSecretName)
+	}
+	for _, nodeSecretName := range fencingLevel.NodeSecretNames {
+		secretNames = append(secretNames, nodeSecretName)
+	}
+	for _, nodeGroup := range fencingLevel.NodeGroups {
+		if nodeGroup.SecretName != nil {
+			secretNames = append(secretNames, *nodeGroup.SecretName)
+		}
+	}
+	for _, secretName := range secretNames {
+		secretData, err := getSecretData(secretName, namespace)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("keys of Secret %s weren't validated: %v", secretName, err))
+			continue
+		}
+		for secretKey, secretVal := range secretData {
+			params[secretKey] = append(params[secretKey], string(secretVal))
+		}
+	}
+
+	var agentWarnings []string
+	var err error
+	if schema := getCatalogSchema(fencingLevel.Agent); schema != nil {
+		agentWarnings, err = schema.ValidateParameters(params)
+	} else {
+		agentWarnings, err = agentValidator.ValidateAgentParameters(fencingLevel.Agent, params)
+	}
+	warnings = append(warnings, agentWarnings...)
+	if err := errors.NewAggregate(append(templateErrs, err)); err != nil {
+		return warnings, fmt.Errorf("invalid parameters of fence agent %s: %w", fencingLevel.Agent, err)
+	}
+	return warnings, nil
+}
+
+// getCatalogSchema returns the parameters schema of the agent's FenceAgent catalog entry, or nil if there is no such entry,
+// e.g. when the catalog wasn't created yet, in which case the agent is validated against the operator image
+func getCatalogSchema(agent string) *validation.AgentSchema {
+	if fenceAgentsReader == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), fenceAgentsReadTimeout)
+	defer cancel()
+	fenceAgent := &FenceAgent{}
+	if err := fenceAgentsReader.Get(ctx, client.ObjectKey{Name: FenceAgentName(agent)}, fenceAgent); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			webhookFARLog.Error(err, "Failed to get the fence agent catalog entry", "agent", agent)
+		}
+		return nil
+	}
+	// the object name doesn't tell apart underscores and dashes, hence the agent is matched as well
+	if fenceAgent.Status.Agent != agent {
+		return nil
+	}
+
+	parameters := make([]validation.AgentParameter, 0, len(fenceAgent.Status.Parameters))
+	for _, parameter := range fenceAgent.Status.Parameters {
+		parameters = append(parameters, validation.AgentParameter{
+			Name:          parameter.Name,
+			Options:       parameter.Options,
+			Type:          parameter.Type,
+			Required:      parameter.Required,
+			Default:       parameter.Default,
+			AllowedValues: parameter.AllowedValues,
+			Deprecated:    parameter.Deprecated,
+			Obsoletes:     parameter.Obsoletes,
+			Description:   parameter.Description,
+		})
+	}
+	return validation.NewAgentSchema(fenceAgent.Status.Description, fenceAgent.Status.Actions, parameters)
+}
+
+// getSecretData returns the data of the Secret, or nil if the Secret doesn't exist or there is no Secrets reader
+func getSecretData(secretName, namespace string) (map[string][]byte, error) {
+	if secretsReader == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretsReadTimeout)
+	defer cancel()
+	secret := &corev1.Secret{}
+	if err := secretsReader.Get(ctx, client.ObjectKey{Name: secretName, Namespace: namespace}, secret); err != nil {
+		if apiErrors.IsNotFound(err) {
+			// the Secrets are optional
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// validateParametersSource validates the fence agent of the fencing level can use the parameters which are derived by its parameters source
+func validateParametersSource(fencingLevel FencingLevel) error {
+	if fencingLevel.ParametersSource == "" {
+		return nil
+	}
+	for _, agent := range parametersSourceAgents[fencingLevel.ParametersSource] {
+		if agent == fencingLevel.Agent {
+			return nil
+		}
+	}
+	return fmt.Errorf("parameters source %s can't be used with fence agent %s, supported agents are %v", fencingLevel.ParametersSource,
+		fencingLevel.Agent, parametersSourceAgents[fencingLevel.ParametersSource])
+}
+
+// validateParametersFrom validates every parameter reference refers to exactly one key of a Secret or a ConfigMap, and that the parameter
+// isn't defined by the parameters of the fencing level as well
+func validateParametersFrom(fencingLevel FencingLevel) error {
+	var errs []error
+	for paramName, valueSource := range fencingLevel.ParametersFrom {
+		switch {
+		case valueSource.SecretKeyRef != nil && valueSource.ConfigMapKeyRef != nil:
+			errs = append(errs, fmt.Errorf("parameter %s refers to both a Secret and a ConfigMap", paramName))
+		case valueSource.SecretKeyRef != nil:
+			if valueSource.SecretKeyRef.Name == "" || valueSource.SecretKeyRef.Key == "" {
+				errs = append(errs, fmt.Errorf("the Secret reference of parameter %s has no name or key", paramName))
+			}
+		case valueSource.ConfigMapKeyRef != nil:
+			if valueSource.ConfigMapKeyRef.Name == "" || valueSource.ConfigMapKeyRef.Key == "" {
+				errs = append(errs, fmt.Errorf("the ConfigMap reference of parameter %s has no name or key", paramName))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("parameter %s refers to neither a Secret nor a ConfigMap", paramName))
+		}
+
+		isDefined := false
+		if _, exists := fencingLevel.SharedParameters[paramName]; exists {
+			isDefined = true
+		}
+		if _, exists := fencingLevel.NodeParameters[paramName]; exists {
+			isDefined = true
+		}
+		for _, nodeGroup := range fencingLevel.NodeGroups {
+			if _, exists := nodeGroup.Parameters[paramName]; exists {
+				isDefined = true
+			}
+		}
+		if isDefined {
+			errs = append(errs, fmt.Errorf("parameter %s is defined both by a reference and by the parameters", paramName))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func validateRebootVerification(farSpec *FenceAgentsRemediationSpec) error {
+	if farSpec.RebootVerification != nil && farSpec.FencingMode == OffOnlyFencingMode {
+		return fmt.Errorf("reboot verification can't be used with the %s fencing mode, since the node isn't powered on again", OffOnlyFencingMode)
+	}
+	return nil
+}
+
+// validateNodeGroups validates the node groups have unique names and valid node selectors, and that no node can be selected by more than one
+// node group, i.e. every two node selectors have contradicting requirements of some label
+func validateNodeGroups(nodeGroups []NodeGroup) error {
+	var errs []error
+	selectors := make([]labels.Selector, len(nodeGroups))
+	for i, nodeGroup := range nodeGroups {
+		for _, otherGroup := range nodeGroups[:i] {
+			if otherGroup.Name == nodeGroup.Name {
+				errs = append(errs, fmt.Errorf("node group name %s is used more than once", nodeGroup.Name))
+			}
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&nodeGroup.NodeSelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid node selector of node group %s: %w", nodeGroup.Name, err))
+			continue
+		}
+		selectors[i] = selector
+	}
+	if len(errs) > 0 {
+		return errors.NewAggregate(errs)
+	}
+
+	for i := range nodeGroups {
+		for j := range nodeGroups[:i] {
+			if !areSelectorsDisjoint(selectors[i], selectors[j]) {
+				errs = append(errs, fmt.Errorf("node groups %s and %s are ambiguous, since their node selectors might select the same node",
+					nodeGroups[j].Name, nodeGroups[i].Name))
+			}
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+// labelRequirements are the requirements of a selector for the value of a single label
+type labelRequirements struct {
+	// values are the allowed values, or nil if any value is allowed
+	values   sets.Set[string]
+	excluded sets.Set[string]
+	exists   bool
+	missing  bool
+}
+
+// areSelectorsDisjoint checks whether no labels can match both selectors, since they have contradicting requirements of some label
+func areSelectorsDisjoint(selector, otherSelector labels.Selector) bool {
+	requirements, otherRequirements := getLabelRequirements(selector), getLabelRequirements(otherSelector)
+	for key, labelReqs := range requirements {
+		otherLabelReqs, exists := otherRequirements[key]
+		if !exists {
+			continue
+		}
+		switch {
+		case labelReqs.missing && otherLabelReqs.exists, labelReqs.exists && otherLabelReqs.missing:
+			return true
+		case labelReqs.values != nil && otherLabelReqs.values != nil && labelReqs.values.Intersection(otherLabelReqs.values).Len() == 0:
+			return true
+		case labelReqs.values != nil && otherLabelReqs.excluded.IsSuperset(labelReqs.values),
+			otherLabelReqs.values != nil && labelReqs.excluded.IsSuperset(otherLabelReqs.values):
+			return true
+		}
+	}
+	return false
+}
+
+// getLabelRequirements groups the requirements of the selector by label
+func getLabelRequirements(selector labels.Selector) map[string]*labelRequirements {
+	requirements := map[string]*labelRequirements{}
+	selectorRequirements, _ := selector.Requirements()
+	for _, requirement := range selectorRequirements {
+		labelReqs, exists := requirements[requirement.Key()]
+		if !exists {
+			labelReqs = &labelRequirements{excluded: sets.New[string]()}
+			requirements[requirement.Key()] = labelReqs
+		}
+		values := sets.New(requirement.Values().UnsortedList()...)
+		switch requirement.Operator() {
+		case selection.In, selection.Equals, selection.DoubleEquals:
+			labelReqs.exists = true
+			if labelReqs.values == nil {
+				labelReqs.values = values
+			} else {
+				labelReqs.values = labelReqs.values.Intersection(values)
+			}
+		case selection.NotIn, selection.NotEquals:
+			labelReqs.excluded = labelReqs.excluded.Union(values)
+		case selection.Exists, selection.GreaterThan, selection.LessThan:
+			labelReqs.exists = true
+		case selection.DoesNotExist:
+			labelReqs.missing = true
+		}
+	}
+	return requirements
+}
+
+// validatePodSelection validates the label selectors of the pod selection
+func validatePodSelection(podSelection *PodSelection) error {
+	if podSelection == nil {
+		return nil
+	}
+	if _, err := metav1.LabelSelectorAsSelector(podSelection.IncludeSelector); err != nil {
+		return fmt.Errorf("invalid pod selection include selector: %w", err)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(podSelection.ExcludeSelector); err != nil {
+		return fmt.Errorf("invalid pod selection exclude selector: %w", err)
+	}
+	return nil
+}
+
 func validateStrategy(farRemStrategy RemediationStrategyType) error {
 	if farRemStrategy == OutOfServiceTaintRemediationStrategy && !isOutOfServiceTaintSupported {
 		return fmt.Errorf("%s remediation strategy is not supported at kubernetes version lower than 1.26, please use a different remediation strategy", OutOfServiceTaintRemediationStrategy)
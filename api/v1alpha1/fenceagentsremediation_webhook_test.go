@@ -1,10 +1,15 @@
 package v1alpha1
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 var _ = Describe("FenceAgentsRemediation Validation", func() {
@@ -27,6 +32,94 @@ var _ = Describe("FenceAgentsRemediation Validation", func() {
 			})
 		})
 
+		When("fallback level agent name was not found", func() {
+			It("should be rejected", func() {
+				far := getTestFAR(validAgentName)
+				far.Spec.FallbackLevels = []FencingLevel{{Agent: validAgentName}, {Agent: invalidAgentName}}
+				warnings, err := far.ValidateCreate()
+				ExpectWithOffset(1, warnings).To(BeEmpty())
+				Expect(err).To(MatchError(ContainSubstring("unsupported fence agent: %s", invalidAgentName)))
+			})
+		})
+
+		Context("with fence agent parameters", func() {
+			var far *FenceAgentsRemediation
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+			})
+
+			When("the parameters match the agent metadata", func() {
+				It("should be accepted without warnings", func() {
+					far.Spec.SharedParameters["--lanplus"] = ""
+					far.Spec.SharedParameters["-l"] = "admin"
+					far.Spec.SharedParameters["--method"] = "cycle"
+					far.Spec.NodeParameters = map[ParameterName]map[NodeName]string{"--ipport": {"worker-0": "6233", "worker-1": "6234"}}
+					warnings, err := far.ValidateCreate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warnings).To(BeEmpty())
+				})
+			})
+
+			When("a parameter is unknown", func() {
+				It("should be rejected", func() {
+					far.Spec.NodeParameters = map[ParameterName]map[NodeName]string{"--ip-port": {"worker-0": "6233"}}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("unknown parameter --ip-port")))
+				})
+			})
+
+			When("a parameter value doesn't match its type", func() {
+				It("should be rejected", func() {
+					far.Spec.NodeParameters = map[ParameterName]map[NodeName]string{"--ipport": {"worker-0": "6233", "worker-1": "port"}}
+					far.Spec.SharedParameters["--method"] = "reset"
+					_, err := far.ValidateCreate()
+					Expect(err).To(MatchError(ContainSubstring("invalid value of parameter --ipport: the value is not an integer")))
+					Expect(err).To(MatchError(ContainSubstring("invalid value of parameter --method: the value is not one of the allowed values")))
+				})
+			})
+
+			When("a parameter value is a node template", func() {
+				It("should be accepted without validating its value", func() {
+					far.Spec.SharedParameters["--ip"] = `{{ .Node.Annotations["bmc.example.com/address"] }}`
+					far.Spec.SharedParameters["--ipport"] = `{{ .Node.Labels["bmc.example.com/port"] }}`
+					warnings, err := far.ValidateCreate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warnings).To(BeEmpty())
+				})
+			})
+
+			When("a node template has an invalid syntax", func() {
+				It("should be rejected", func() {
+					far.Spec.SharedParameters["--ip"] = `{{ .Node.Annotations[bmc.example.com/address] }}`
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("invalid template of parameter --ip")))
+				})
+			})
+
+			When("a required parameter is missing", func() {
+				It("should be accepted with a warning", func() {
+					delete(far.Spec.SharedParameters, "--ip")
+					warnings, err := far.ValidateCreate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warnings).To(ConsistOf("required parameter ip is missing"))
+				})
+			})
+
+			When("a Secret key is unknown", func() {
+				It("should be rejected", func() {
+					secret := &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-shared-secret", Namespace: "default"},
+						Data:       map[string][]byte{"--password": []byte("password"), "--pasword": []byte("password")},
+					}
+					Expect(k8sClient.Create(context.Background(), secret)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), secret)
+
+					far.Namespace = "default"
+					far.Spec.SharedSecretName = &secret.Name
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("unknown parameter --pasword")))
+				})
+			})
+		})
+
 		Context("with OutOfServiceTaint strategy", func() {
 			var outOfServiceStrategy *FenceAgentsRemediation
 
@@ -55,6 +148,216 @@ var _ = Describe("FenceAgentsRemediation Validation", func() {
 				})
 			})
 		})
+
+		Context("with reboot verification", func() {
+			var far *FenceAgentsRemediation
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+				far.Spec.RebootVerification = &NodeRebootVerification{Timeout: metav1.Duration{Duration: 10 * time.Minute}}
+			})
+			When("the node is rebooted", func() {
+				It("should be accepted", func() {
+					far.Spec.FencingMode = OffThenOnFencingMode
+					Expect(far.ValidateCreate()).Error().NotTo(HaveOccurred())
+				})
+			})
+			When("the node is only powered off", func() {
+				It("should be rejected", func() {
+					far.Spec.FencingMode = OffOnlyFencingMode
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("reboot verification can't be used with the OffOnly fencing mode")))
+				})
+			})
+		})
+
+		Context("with node groups", func() {
+			var far *FenceAgentsRemediation
+			rackGroup := func(name string, selector metav1.LabelSelector) NodeGroup {
+				return NodeGroup{Name: name, NodeSelector: selector, Parameters: map[ParameterName]string{"--ip": "192.168.111.1"}}
+			}
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+			})
+			When("no node can be selected by two node groups", func() {
+				It("should be accepted", func() {
+					far.Spec.NodeGroups = []NodeGroup{
+						rackGroup("rack-a", metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}}),
+						rackGroup("rack-b", metav1.LabelSelector{MatchLabels: map[string]string{"rack": "b", "role": "worker"}}),
+						rackGroup("other-racks", metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "rack", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a", "b"}},
+						}}),
+					}
+					Expect(far.ValidateCreate()).Error().NotTo(HaveOccurred())
+				})
+			})
+			When("a node can be selected by two node groups", func() {
+				It("should be rejected", func() {
+					far.Spec.NodeGroups = []NodeGroup{
+						rackGroup("rack-a", metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}}),
+						rackGroup("workers", metav1.LabelSelector{MatchLabels: map[string]string{"role": "worker"}}),
+					}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("node groups rack-a and workers are ambiguous")))
+				})
+			})
+			When("two node groups have the same name", func() {
+				It("should be rejected", func() {
+					far.Spec.NodeGroups = []NodeGroup{
+						rackGroup("rack", metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}}),
+						rackGroup("rack", metav1.LabelSelector{MatchLabels: map[string]string{"rack": "b"}}),
+					}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("node group name rack is used more than once")))
+				})
+			})
+		})
+
+		Context("with BareMetalHost parameters source", func() {
+			var far *FenceAgentsRemediation
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+				far.Spec.ParametersSource = BareMetalHostParametersSource
+			})
+			When("the agent manages BMCs", func() {
+				It("should be accepted without warnings of the derived parameters", func() {
+					delete(far.Spec.SharedParameters, "--ip")
+					warnings, err := far.ValidateCreate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warnings).To(BeEmpty())
+				})
+			})
+			When("the agent doesn't manage BMCs", func() {
+				It("should be rejected", func() {
+					far.Spec.Agent = invalidAgentName
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("parameters source BareMetalHost can't be used with fence agent %s",
+						invalidAgentName)))
+				})
+			})
+		})
+
+		Context("with ProviderID parameters source", func() {
+			When("the agent doesn't manage cloud instances", func() {
+				It("should be rejected", func() {
+					far := getTestFAR(validAgentName)
+					far.Spec.ParametersSource = ProviderIDParametersSource
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("parameters source ProviderID can't be used with fence agent %s",
+						validAgentName)))
+				})
+			})
+		})
+
+		Context("with parameter references", func() {
+			var far *FenceAgentsRemediation
+			secretKeyRef := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bmc-creds"}, Key: "pw"}
+			configMapKeyRef := &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bmc-config"}, Key: "address"}
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+			})
+			When("every parameter refers to a single key", func() {
+				It("should be accepted without warnings", func() {
+					delete(far.Spec.SharedParameters, "--ip")
+					far.Spec.ParametersFrom = map[ParameterName]ParameterValueSource{
+						"--ip":       {ConfigMapKeyRef: configMapKeyRef},
+						"--password": {SecretKeyRef: secretKeyRef},
+					}
+					warnings, err := far.ValidateCreate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(warnings).To(BeEmpty())
+				})
+			})
+			When("a parameter refers to both a Secret and a ConfigMap", func() {
+				It("should be rejected", func() {
+					far.Spec.ParametersFrom = map[ParameterName]ParameterValueSource{
+						"--password": {SecretKeyRef: secretKeyRef, ConfigMapKeyRef: configMapKeyRef},
+					}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("parameter --password refers to both a Secret and a ConfigMap")))
+				})
+			})
+			When("a referenced parameter is defined by the parameters as well", func() {
+				It("should be rejected", func() {
+					far.Spec.ParametersFrom = map[ParameterName]ParameterValueSource{"--ip": {ConfigMapKeyRef: configMapKeyRef}}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("parameter --ip is defined both by a reference and by the parameters")))
+				})
+			})
+		})
+
+		Context("with pod selection", func() {
+			var far *FenceAgentsRemediation
+
+			BeforeEach(func() {
+				far = getTestFAR(validAgentName)
+			})
+			When("the label selectors are valid", func() {
+				It("should be accepted", func() {
+					far.Spec.PodSelection = &PodSelection{
+						ExcludedNamespaces: []string{"openshift-storage"},
+						ExcludeSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "storage"}},
+						SkipDaemonSetPods:  true,
+					}
+					Expect(far.ValidateCreate()).Error().NotTo(HaveOccurred())
+				})
+			})
+			When("a label selector is invalid", func() {
+				It("should be rejected", func() {
+					far.Spec.PodSelection = &PodSelection{IncludeSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}},
+					}}
+					Expect(far.ValidateCreate()).Error().To(MatchError(ContainSubstring("invalid pod selection include selector")))
+				})
+			})
+		})
+
+		Context("with a fence agent catalog entry", func() {
+			// the agent isn't installed in the operator image of the test, hence it is known only by its catalog entry
+			const catalogAgentName = "fence_catalog"
+
+			BeforeEach(func() {
+				fenceAgent := &FenceAgent{ObjectMeta: metav1.ObjectMeta{Name: FenceAgentName(catalogAgentName)}}
+				Expect(k8sClient.Create(context.Background(), fenceAgent)).To(Succeed())
+				DeferCleanup(k8sClient.Delete, context.Background(), fenceAgent)
+				fenceAgent.Status = FenceAgentStatus{
+					Agent: catalogAgentName,
+					Parameters: []FenceAgentParameter{
+						{Name: "ip", Options: []string{"--ip", "-a"}, Type: "string", Required: true},
+						{Name: "port", Options: []string{"--port", "-n"}, Type: "integer"},
+					},
+				}
+				Expect(k8sClient.Status().Update(context.Background(), fenceAgent)).To(Succeed())
+			})
+
+			When("the parameters match the catalog entry", func() {
+				It("should be accepted", func() {
+					far := getTestFAR(catalogAgentName)
+					far.Spec.SharedParameters["--port"] = "6233"
+					Eventually(func() (admission.Warnings, error) {
+						return far.ValidateCreate()
+					}).Should(BeEmpty())
+				})
+			})
+
+			When("a parameter is unknown to the catalog entry", func() {
+				It("should be rejected", func() {
+					far := getTestFAR(catalogAgentName)
+					far.Spec.SharedParameters["--ipport"] = "6233"
+					Eventually(func() error {
+						_, err := far.ValidateCreate()
+						return err
+					}).Should(MatchError(ContainSubstring("unknown parameter --ipport")))
+				})
+			})
+
+			When("a parameter value doesn't match the catalog entry", func() {
+				It("should be rejected", func() {
+					far := getTestFAR(catalogAgentName)
+					far.Spec.SharedParameters["--port"] = "port"
+					Eventually(func() error {
+						_, err := far.ValidateCreate()
+						return err
+					}).Should(MatchError(ContainSubstring("invalid value of parameter --port: the value is not an integer")))
+				})
+			})
+		})
 	})
 
 	Context("updating FenceAgentsRemediation", func() {
@@ -81,6 +384,35 @@ var _ = Describe("FenceAgentsRemediation Validation", func() {
 			})
 		})
 
+		Context("with fence agent parameters", func() {
+			var far *FenceAgentsRemediation
+
+			BeforeEach(func() {
+				oldFAR = getTestFAR(validAgentName)
+				oldFAR.Spec.SharedParameters["--ip-port"] = "6233"
+				far = oldFAR.DeepCopy()
+			})
+			When("the spec has changed", func() {
+				It("should validate the parameters", func() {
+					far.Spec.SharedParameters["--lanplus"] = ""
+					Expect(far.ValidateUpdate(oldFAR)).Error().To(MatchError(ContainSubstring("unknown parameter --ip-port")))
+				})
+			})
+			When("the spec hasn't changed", func() {
+				It("shouldn't validate the parameters", func() {
+					far.Finalizers = []string{FARFinalizer}
+					Expect(far.ValidateUpdate(oldFAR)).Error().NotTo(HaveOccurred())
+				})
+			})
+			When("the CR is being deleted", func() {
+				It("shouldn't validate the parameters", func() {
+					far.Spec.SharedParameters["--lanplus"] = ""
+					far.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+					Expect(far.ValidateUpdate(oldFAR)).Error().NotTo(HaveOccurred())
+				})
+			})
+		})
+
 		Context("with OutOfServiceTaint strategy", func() {
 			var outOfServiceStrategy *FenceAgentsRemediation
 			var resourceDeletionStrategy *FenceAgentsRemediation
@@ -126,6 +458,7 @@ func getFAR(agentName string, strategy RemediationStrategyType) *FenceAgentsReme
 		Spec: FenceAgentsRemediationSpec{
 			Agent:               agentName,
 			RemediationStrategy: strategy,
+			SharedParameters:    map[ParameterName]string{"--ip": "192.168.111.1"},
 		},
 	}
 }
@@ -35,12 +35,85 @@ type FenceAgentsRemediationTemplateSpec struct {
 	// Template defines the desired state of FenceAgentsRemediationTemplate
 	//+operator-sdk:csv:customresourcedefinitions:type=spec
 	Template FenceAgentsRemediationTemplateResource `json:"template"`
+
+	// ReadinessCheck enables a periodic check which runs the fence agent status action for each node of the template's node parameters
+	// and node secrets, in order to find broken fencing devices or credentials before a node has to be remediated.
+	// The results are reported in the template status. The check is disabled when ReadinessCheck isn't set.
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	ReadinessCheck *FencingReadinessCheck `json:"readinessCheck,omitempty"`
+}
+
+// FencingReadinessCheck defines how often the fencing readiness of the template's nodes is checked
+type FencingReadinessCheck struct {
+	// Interval is the interval between each check of all the nodes
+	// +kubebuilder:default:="10m"
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type=string
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// MaxConcurrentChecks is the maximum number of nodes which are checked at the same time
+	// +kubebuilder:default:=5
+	// +kubebuilder:validation:Minimum=1
+	//+operator-sdk:csv:customresourcedefinitions:type=spec
+	MaxConcurrentChecks int `json:"maxConcurrentChecks,omitempty"`
+}
+
+// NodeFencingReadiness is the fencing readiness of a node, as reported by the last readiness check
+type NodeFencingReadiness struct {
+	// NodeName is the name of the checked node
+	NodeName NodeName `json:"nodeName"`
+
+	// Represents the node's fencing readiness.
+	// Known .conditions.type are: "FencingReady".
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastCheckTime is the last time the node was checked
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// LastError is the reason the fence agents couldn't report the node's power status in the last check, if any
+	// +optional
+	LastError string `json:"lastError,omitempty"`
 }
 
 // FenceAgentsRemediationTemplateStatus defines the observed state of FenceAgentsRemediationTemplate
 type FenceAgentsRemediationTemplateStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Represents the fencing readiness of all the checked nodes.
+	// Known .status.conditions.type are: "FencingReady".
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Nodes is the fencing readiness of each checked node
+	// +listType=map
+	// +listMapKey=nodeName
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	Nodes []NodeFencingReadiness `json:"nodes,omitempty"`
+
+	// LastCheckTime is the last time the readiness check has run
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// ObservedGeneration is the template generation which the last readiness check has checked
+	// +optional
+	//+operator-sdk:csv:customresourcedefinitions:type=status
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -32,6 +32,9 @@ var (
 )
 
 func (r *FenceAgentsRemediationTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	// the API reader doesn't start a cluster wide informer for Secrets, which the operator isn't allowed to watch
+	secretsReader = mgr.GetAPIReader()
+	fenceAgentsReader = mgr.GetClient()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -62,13 +65,15 @@ var _ webhook.Validator = &FenceAgentsRemediationTemplate{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (farTemplate *FenceAgentsRemediationTemplate) ValidateCreate() (admission.Warnings, error) {
 	webhookFARTemplateLog.Info("validate create", "name", farTemplate.Name)
-	return validateFAR(&farTemplate.Spec.Template.Spec)
+	return validateFAR(&farTemplate.Spec.Template.Spec, farTemplate.Namespace, true)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (farTemplate *FenceAgentsRemediationTemplate) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
 	webhookFARTemplateLog.Info("validate update", "name", farTemplate.Name)
-	return validateFAR(&farTemplate.Spec.Template.Spec)
+	oldTemplate, isTemplate := old.(*FenceAgentsRemediationTemplate)
+	return validateFAR(&farTemplate.Spec.Template.Spec, farTemplate.Namespace,
+		!isTemplate || isParametersValidationRequired(farTemplate, &farTemplate.Spec.Template.Spec, &oldTemplate.Spec.Template.Spec))
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -0,0 +1,136 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FenceAgentsRemediationTestSpec defines which FenceAgentsRemediationTemplate is tested, and on which nodes
+type FenceAgentsRemediationTestSpec struct {
+	// TemplateName is the name of the FenceAgentsRemediationTemplate to test, in the namespace of the test
+	// +kubebuilder:validation:MinLength=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	TemplateName string `json:"templateName"`
+
+	// NodeSelector selects the nodes to test. All the nodes are tested when both NodeSelector and NodeNames are empty.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// NodeNames are the names of the nodes to test, in addition to the nodes which are selected by NodeSelector
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	NodeNames []NodeName `json:"nodeNames,omitempty"`
+}
+
+// NodeFencingTestResult is the result of running a fencing level's status action for a node
+type NodeFencingTestResult struct {
+	// NodeName is the name of the tested node
+	NodeName NodeName `json:"nodeName"`
+
+	// Level is the tested fencing level, where 1 is the fencing level of the template spec, and the fallback levels follow it
+	Level int `json:"level"`
+
+	// Agent is the fence agent of the fencing level
+	Agent string `json:"agent"`
+
+	// Reachable is true if every fencing device of the fencing level has reported the node's power status
+	Reachable bool `json:"reachable"`
+
+	// PowerStatus is the node's power status as reported by the fence agent, e.g. "ON" or "OFF"
+	// +optional
+	PowerStatus string `json:"powerStatus,omitempty"`
+
+	// Latency is how long the fence agent took to report the power status of all the fencing devices
+	// +optional
+	// +kubebuilder:validation:Type=string
+	Latency metav1.Duration `json:"latency,omitempty"`
+
+	// Error is the reason the fence agent couldn't report the node's power status
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// FenceAgentsRemediationTestStatus defines the observed state of FenceAgentsRemediationTest
+type FenceAgentsRemediationTestStatus struct {
+	// Results are the test results per node and fencing level
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Results []NodeFencingTestResult `json:"results,omitempty"`
+
+	// ReachableNodes is the number of tested nodes whose power status was reported by all the fencing levels
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	ReachableNodes int `json:"reachableNodes,omitempty"`
+
+	// UnreachableNodes is the number of tested nodes whose power status wasn't reported by at least one fencing level
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	UnreachableNodes int `json:"unreachableNodes,omitempty"`
+
+	// Error is the reason the test couldn't run, e.g. a missing template
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Error string `json:"error,omitempty"`
+
+	// StartTime is the time the test has started. It is recorded before any fencing device is contacted.
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the test has completed. A completed test isn't run again.
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=fartest
+// +kubebuilder:printcolumn:name="Template",type="string",JSONPath=".spec.templateName"
+// +kubebuilder:printcolumn:name="Reachable",type="integer",JSONPath=".status.reachableNodes"
+// +kubebuilder:printcolumn:name="Unreachable",type="integer",JSONPath=".status.unreachableNodes"
+// +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime"
+
+// FenceAgentsRemediationTest runs the fence agent status action with the parameters of a FenceAgentsRemediationTemplate
+// for each selected node, in order to verify the credentials and addresses of the template without fencing any node
+// +operator-sdk:csv:customresourcedefinitions:resources={{"FenceAgentsRemediationTest","v1alpha1","fenceagentsremediationtests"}}
+type FenceAgentsRemediationTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FenceAgentsRemediationTestSpec   `json:"spec,omitempty"`
+	Status FenceAgentsRemediationTestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FenceAgentsRemediationTestList contains a list of FenceAgentsRemediationTest
+type FenceAgentsRemediationTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FenceAgentsRemediationTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FenceAgentsRemediationTest{}, &FenceAgentsRemediationTestList{})
+}
@@ -30,6 +30,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -51,6 +52,42 @@ const (
 	validAgentName                  = "fence_ipmilan"
 	invalidAgentName                = "fence_ip"
 	outOfServiceTaintUnsupportedMsg = "OutOfServiceTaint remediation strategy is not supported at kubernetes version lower than 1.26, please use a different remediation strategy"
+	validAgentMetadata              = `<?xml version="1.0" ?>
+<resource-agent name="fence_ipmilan" shortdesc="Fence agent for IPMI">
+<parameters>
+	<parameter name="action" unique="0" required="1">
+		<getopt mixed="-o, --action=[action]" />
+		<content type="string" default="reboot" />
+	</parameter>
+	<parameter name="ip" unique="0" required="1" obsoletes="ipaddr">
+		<getopt mixed="-a, --ip=[ip]" />
+		<content type="string" />
+	</parameter>
+	<parameter name="ipport" unique="0" required="0">
+		<getopt mixed="-u, --ipport=[port]" />
+		<content type="integer" default="623" />
+	</parameter>
+	<parameter name="lanplus" unique="0" required="0">
+		<getopt mixed="-P, --lanplus" />
+		<content type="boolean" />
+	</parameter>
+	<parameter name="method" unique="0" required="0">
+		<getopt mixed="-m, --method=[method]" />
+		<content type="select" default="onoff">
+			<option value="onoff" />
+			<option value="cycle" />
+		</content>
+	</parameter>
+	<parameter name="password" unique="0" required="0">
+		<getopt mixed="-p, --password=[password]" />
+		<content type="string" />
+	</parameter>
+	<parameter name="username" unique="0" required="0">
+		<getopt mixed="-l, --username=[name]" />
+		<content type="string" />
+	</parameter>
+</parameters>
+</resource-agent>`
 )
 
 var (
@@ -94,6 +131,9 @@ var _ = BeforeSuite(func() {
 	err = admissionv1beta1.AddToScheme(scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = corev1.AddToScheme(scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
@@ -101,11 +141,14 @@ var _ = BeforeSuite(func() {
 	Expect(k8sClient).NotTo(BeNil())
 
 	// initalize webhook agentValidator with a dummy function to check if agents name match the validAgentName
+	// and with the metadata of the validAgentName
 	agentValidator = validation.NewCustomAgentValidator(func(agent string) (bool, error) {
 		if strings.Contains(agent, validAgentName) {
 			return true, nil
 		}
 		return false, nil
+	}, func(agent string) ([]byte, error) {
+		return []byte(validAgentMetadata), nil
 	})
 
 	// start webhook server using Manager
@@ -21,10 +21,185 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgent) DeepCopyInto(out *FenceAgent) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgent.
+func (in *FenceAgent) DeepCopy() *FenceAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FenceAgent) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentAttempt) DeepCopyInto(out *FenceAgentAttempt) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.EndTime.DeepCopyInto(&out.EndTime)
+	if in.QueueWait != nil {
+		in, out := &in.QueueWait, &out.QueueWait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentAttempt.
+func (in *FenceAgentAttempt) DeepCopy() *FenceAgentAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentExecution) DeepCopyInto(out *FenceAgentExecution) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentExecution.
+func (in *FenceAgentExecution) DeepCopy() *FenceAgentExecution {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentExecution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentList) DeepCopyInto(out *FenceAgentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FenceAgent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentList.
+func (in *FenceAgentList) DeepCopy() *FenceAgentList {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FenceAgentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentParameter) DeepCopyInto(out *FenceAgentParameter) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedValues != nil {
+		in, out := &in.AllowedValues, &out.AllowedValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentParameter.
+func (in *FenceAgentParameter) DeepCopy() *FenceAgentParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentStatus) DeepCopyInto(out *FenceAgentStatus) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]FenceAgentParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentStatus.
+func (in *FenceAgentStatus) DeepCopy() *FenceAgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentVerification) DeepCopyInto(out *FenceAgentVerification) {
+	*out = *in
+	out.Timeout = in.Timeout
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentVerification.
+func (in *FenceAgentVerification) DeepCopy() *FenceAgentVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FenceAgentsRemediation) DeepCopyInto(out *FenceAgentsRemediation) {
 	*out = *in
@@ -114,6 +289,13 @@ func (in *FenceAgentsRemediationSpec) DeepCopyInto(out *FenceAgentsRemediationSp
 			(*out)[key] = outVal
 		}
 	}
+	if in.NodeGroups != nil {
+		in, out := &in.NodeGroups, &out.NodeGroups
+		*out = make([]NodeGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.NodeSecretNames != nil {
 		in, out := &in.NodeSecretNames, &out.NodeSecretNames
 		*out = make(map[NodeName]string, len(*in))
@@ -126,6 +308,42 @@ func (in *FenceAgentsRemediationSpec) DeepCopyInto(out *FenceAgentsRemediationSp
 		*out = new(string)
 		**out = **in
 	}
+	if in.ParametersFrom != nil {
+		in, out := &in.ParametersFrom, &out.ParametersFrom
+		*out = make(map[ParameterName]ParameterValueSource, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(FenceAgentVerification)
+		**out = **in
+	}
+	if in.RebootVerification != nil {
+		in, out := &in.RebootVerification, &out.RebootVerification
+		*out = new(NodeRebootVerification)
+		**out = **in
+	}
+	if in.FallbackLevels != nil {
+		in, out := &in.FallbackLevels, &out.FallbackLevels
+		*out = make([]FencingLevel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]FencingDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodSelection != nil {
+		in, out := &in.PodSelection, &out.PodSelection
+		*out = new(PodSelection)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationSpec.
@@ -152,6 +370,31 @@ func (in *FenceAgentsRemediationStatus) DeepCopyInto(out *FenceAgentsRemediation
 		in, out := &in.LastUpdateTime, &out.LastUpdateTime
 		*out = (*in).DeepCopy()
 	}
+	if in.FencedBy != nil {
+		in, out := &in.FencedBy, &out.FencedBy
+		*out = new(FencingLevelStatus)
+		**out = **in
+	}
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]FenceAgentAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Execution != nil {
+		in, out := &in.Execution, &out.Execution
+		*out = new(FenceAgentExecution)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FencingHandOffTime != nil {
+		in, out := &in.FencingHandOffTime, &out.FencingHandOffTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WorkloadsRemovedTime != nil {
+		in, out := &in.WorkloadsRemovedTime, &out.WorkloadsRemovedTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationStatus.
@@ -170,7 +413,7 @@ func (in *FenceAgentsRemediationTemplate) DeepCopyInto(out *FenceAgentsRemediati
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTemplate.
@@ -243,6 +486,11 @@ func (in *FenceAgentsRemediationTemplateResource) DeepCopy() *FenceAgentsRemedia
 func (in *FenceAgentsRemediationTemplateSpec) DeepCopyInto(out *FenceAgentsRemediationTemplateSpec) {
 	*out = *in
 	in.Template.DeepCopyInto(&out.Template)
+	if in.ReadinessCheck != nil {
+		in, out := &in.ReadinessCheck, &out.ReadinessCheck
+		*out = new(FencingReadinessCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTemplateSpec.
@@ -258,6 +506,24 @@ func (in *FenceAgentsRemediationTemplateSpec) DeepCopy() *FenceAgentsRemediation
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FenceAgentsRemediationTemplateStatus) DeepCopyInto(out *FenceAgentsRemediationTemplateStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeFencingReadiness, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTemplateStatus.
@@ -269,3 +535,402 @@ func (in *FenceAgentsRemediationTemplateStatus) DeepCopy() *FenceAgentsRemediati
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentsRemediationTest) DeepCopyInto(out *FenceAgentsRemediationTest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTest.
+func (in *FenceAgentsRemediationTest) DeepCopy() *FenceAgentsRemediationTest {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentsRemediationTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FenceAgentsRemediationTest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentsRemediationTestList) DeepCopyInto(out *FenceAgentsRemediationTestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FenceAgentsRemediationTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTestList.
+func (in *FenceAgentsRemediationTestList) DeepCopy() *FenceAgentsRemediationTestList {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentsRemediationTestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FenceAgentsRemediationTestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentsRemediationTestSpec) DeepCopyInto(out *FenceAgentsRemediationTestSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeNames != nil {
+		in, out := &in.NodeNames, &out.NodeNames
+		*out = make([]NodeName, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTestSpec.
+func (in *FenceAgentsRemediationTestSpec) DeepCopy() *FenceAgentsRemediationTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentsRemediationTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FenceAgentsRemediationTestStatus) DeepCopyInto(out *FenceAgentsRemediationTestStatus) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]NodeFencingTestResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FenceAgentsRemediationTestStatus.
+func (in *FenceAgentsRemediationTestStatus) DeepCopy() *FenceAgentsRemediationTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FenceAgentsRemediationTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FencingDevice) DeepCopyInto(out *FencingDevice) {
+	*out = *in
+	if in.SharedParameters != nil {
+		in, out := &in.SharedParameters, &out.SharedParameters
+		*out = make(map[ParameterName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeParameters != nil {
+		in, out := &in.NodeParameters, &out.NodeParameters
+		*out = make(map[ParameterName]map[NodeName]string, len(*in))
+		for key, val := range *in {
+			var outVal map[NodeName]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[NodeName]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FencingDevice.
+func (in *FencingDevice) DeepCopy() *FencingDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(FencingDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FencingLevel) DeepCopyInto(out *FencingLevel) {
+	*out = *in
+	out.RetryInterval = in.RetryInterval
+	out.Timeout = in.Timeout
+	if in.SharedParameters != nil {
+		in, out := &in.SharedParameters, &out.SharedParameters
+		*out = make(map[ParameterName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeParameters != nil {
+		in, out := &in.NodeParameters, &out.NodeParameters
+		*out = make(map[ParameterName]map[NodeName]string, len(*in))
+		for key, val := range *in {
+			var outVal map[NodeName]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(map[NodeName]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NodeGroups != nil {
+		in, out := &in.NodeGroups, &out.NodeGroups
+		*out = make([]NodeGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSecretNames != nil {
+		in, out := &in.NodeSecretNames, &out.NodeSecretNames
+		*out = make(map[NodeName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SharedSecretName != nil {
+		in, out := &in.SharedSecretName, &out.SharedSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ParametersFrom != nil {
+		in, out := &in.ParametersFrom, &out.ParametersFrom
+		*out = make(map[ParameterName]ParameterValueSource, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]FencingDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FencingLevel.
+func (in *FencingLevel) DeepCopy() *FencingLevel {
+	if in == nil {
+		return nil
+	}
+	out := new(FencingLevel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FencingLevelStatus) DeepCopyInto(out *FencingLevelStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FencingLevelStatus.
+func (in *FencingLevelStatus) DeepCopy() *FencingLevelStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FencingLevelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FencingReadinessCheck) DeepCopyInto(out *FencingReadinessCheck) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FencingReadinessCheck.
+func (in *FencingReadinessCheck) DeepCopy() *FencingReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(FencingReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeFencingReadiness) DeepCopyInto(out *NodeFencingReadiness) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeFencingReadiness.
+func (in *NodeFencingReadiness) DeepCopy() *NodeFencingReadiness {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeFencingReadiness)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeFencingTestResult) DeepCopyInto(out *NodeFencingTestResult) {
+	*out = *in
+	out.Latency = in.Latency
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeFencingTestResult.
+func (in *NodeFencingTestResult) DeepCopy() *NodeFencingTestResult {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeFencingTestResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGroup) DeepCopyInto(out *NodeGroup) {
+	*out = *in
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[ParameterName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretName != nil {
+		in, out := &in.SecretName, &out.SecretName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGroup.
+func (in *NodeGroup) DeepCopy() *NodeGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeRebootVerification) DeepCopyInto(out *NodeRebootVerification) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeRebootVerification.
+func (in *NodeRebootVerification) DeepCopy() *NodeRebootVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeRebootVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterValueSource) DeepCopyInto(out *ParameterValueSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterValueSource.
+func (in *ParameterValueSource) DeepCopy() *ParameterValueSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterValueSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSelection) DeepCopyInto(out *PodSelection) {
+	*out = *in
+	if in.ExcludedNamespaces != nil {
+		in, out := &in.ExcludedNamespaces, &out.ExcludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeSelector != nil {
+		in, out := &in.IncludeSelector, &out.IncludeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludeSelector != nil {
+		in, out := &in.ExcludeSelector, &out.ExcludeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSelection.
+func (in *PodSelection) DeepCopy() *PodSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSelection)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -48,18 +49,25 @@ import (
 const defaultNamespace = "default"
 
 var (
-	k8sClient    client.Client
-	k8sManager   manager.Manager
-	testEnv      *envtest.Environment
-	ctx          context.Context
-	cancel       context.CancelFunc
-	fakeRecorder *record.FakeRecorder
+	k8sClient     client.Client
+	k8sManager    manager.Manager
+	testEnv       *envtest.Environment
+	ctx           context.Context
+	cancel        context.CancelFunc
+	fakeRecorder  *record.FakeRecorder
+	executor      *cli.Executer
+	farReconciler *FenceAgentsRemediationReconciler
 
 	plogs *peekLogger
 
-	storedCommand []string
-	mockError     error
-	forcedDelay   time.Duration
+	storedCommand       []string
+	storedStatusCommand []string
+	storedCommands      [][]string
+	storedOverStdin     bool
+	mockError           error
+	mockFailingAgent    string
+	mockPowerStatus     = cli.PowerStatusOn
+	forcedDelay         time.Duration
 )
 
 // peekLogger allows to inspect operator's log for testing purpose.
@@ -116,7 +124,7 @@ var _ = BeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases"), filepath.Join("testdata", "crd")},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -137,15 +145,33 @@ var _ = BeforeSuite(func() {
 	Expect(k8sClient).NotTo(BeNil())
 
 	fakeRecorder = record.NewFakeRecorder(30)
-	executor := cli.NewFakeExecuter(k8sClient, controlledRun, fakeRecorder)
+	executor = cli.NewFakeExecuter(k8sClient, controlledRun, fakeRecorder)
 	os.Setenv("DEPLOYMENT_NAMESPACE", defaultNamespace)
 
-	err = (&FenceAgentsRemediationReconciler{
+	farReconciler = &FenceAgentsRemediationReconciler{
 		Client:   k8sClient,
 		Log:      k8sManager.GetLogger().WithName("test far reconciler"),
 		Scheme:   k8sManager.GetScheme(),
 		Recorder: fakeRecorder,
 		Executor: executor,
+		PodName:  farPodName,
+	}
+	err = farReconciler.SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&FenceAgentsRemediationTemplateReconciler{
+		Client:   k8sClient,
+		Log:      k8sManager.GetLogger().WithName("test far template reconciler"),
+		Recorder: fakeRecorder,
+		Executor: executor,
+	}).SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&FenceAgentsRemediationTestReconciler{
+		Client:   k8sClient,
+		Log:      k8sManager.GetLogger().WithName("test far test reconciler"),
+		Recorder: fakeRecorder,
+		Executor: executor,
 	}).SetupWithManager(k8sManager)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -164,8 +190,47 @@ var _ = AfterSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 })
 
-func controlledRun(ctx context.Context, command []string) (stdout, stderr string, err error) {
+// simulateManagerRestart simulates a manager restart while the fence agent is running: the execution is handed to the previous manager's
+// identity, the fence agent routines are killed with the previous manager, and the new manager reconciles the CR once it starts
+func simulateManagerRestart(far *v1alpha1.FenceAgentsRemediation, previousIdentity string) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		restartedFar := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), restartedFar)).To(Succeed())
+		g.Expect(restartedFar.Status.Execution).ToNot(BeNil())
+		restartedFar.Status.Execution.Owner = previousIdentity
+		g.Expect(k8sClient.Status().Update(context.Background(), restartedFar)).To(Succeed())
+	}, timeoutPreRemediation, pollInterval).Should(Succeed())
+	executor.SimulateRestart()
+	triggerReconcile(far)
+}
+
+// triggerReconcile triggers a reconcile of the CR by annotating it, rather than waiting for a periodic requeue
+func triggerReconcile(far *v1alpha1.FenceAgentsRemediation) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		reconciledFar := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), reconciledFar)).To(Succeed())
+		if reconciledFar.Annotations == nil {
+			reconciledFar.Annotations = map[string]string{}
+		}
+		reconciledFar.Annotations["test/reconcile-trigger"] = time.Now().String()
+		g.Expect(k8sClient.Update(context.Background(), reconciledFar)).To(Succeed())
+	}, timeoutPreRemediation, pollInterval).Should(Succeed())
+}
+
+func controlledRun(ctx context.Context, command []string, parametersOverStdin bool) (stdout, stderr string, err error) {
+	if slices.Contains(command, parameterActionName+"="+parameterStatusActionValue) {
+		storedStatusCommand = command
+		if command[0] == mockFailingAgent {
+			return "", "Failed: Unable to connect", fmt.Errorf("mock %s failure", mockFailingAgent)
+		}
+		return fmt.Sprintf("Status: %s\n", mockPowerStatus), "", nil
+	}
 	storedCommand = command
+	storedOverStdin = parametersOverStdin
+	storedCommands = append(storedCommands, command)
+	if command[0] == mockFailingAgent {
+		return "", "", fmt.Errorf("mock %s failure", mockFailingAgent)
+	}
 	if forcedDelay > 0 {
 		select {
 		case <-ctx.Done():
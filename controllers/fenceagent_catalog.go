@@ -0,0 +1,146 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilErrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/validation"
+)
+
+// FenceAgentCatalog publishes a FenceAgent object for each fence agent which is installed in the operator image,
+// so users can discover the available agents, their actions and their parameters without exec'ing into the operator pod
+type FenceAgentCatalog struct {
+	client.Client
+	Log logr.Logger
+	// ListAgents returns the names of the installed fence agents
+	ListAgents func() ([]string, error)
+	// GetMetadata returns the metadata XML of the fence agent
+	GetMetadata validation.AgentMetadataGetter
+}
+
+//+kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagents,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagents/status,verbs=get;update;patch
+
+// Start syncs the FenceAgent objects with the installed fence agents once, since the agents don't change while the operator runs
+func (c *FenceAgentCatalog) Start(ctx context.Context) error {
+	agents, err := c.ListAgents()
+	if err != nil {
+		c.Log.Error(err, "Failed to list the installed fence agents")
+		return nil
+	}
+
+	var errs []error
+	installedAgents := make(map[string]bool, len(agents))
+	for _, agent := range agents {
+		if err := c.syncFenceAgent(ctx, agent); err != nil {
+			c.Log.Error(err, "Failed to sync fence agent catalog entry", "Agent", agent)
+			errs = append(errs, err)
+			continue
+		}
+		installedAgents[v1alpha1.FenceAgentName(agent)] = true
+	}
+
+	// remove the entries of agents which are no longer installed, e.g. after an operator upgrade
+	fenceAgents := &v1alpha1.FenceAgentList{}
+	if err := c.List(ctx, fenceAgents, client.HasLabels{v1alpha1.FenceAgentCatalogLabel}); err != nil {
+		errs = append(errs, err)
+	} else {
+		for i := range fenceAgents.Items {
+			fenceAgent := &fenceAgents.Items[i]
+			if installedAgents[fenceAgent.Name] {
+				continue
+			}
+			c.Log.Info("Removing catalog entry of a fence agent which is not installed", "FenceAgent", fenceAgent.Name)
+			if err := c.Delete(ctx, fenceAgent); err != nil && !apiErrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := utilErrors.NewAggregate(errs); err != nil {
+		// a partial catalog doesn't affect remediation, thus the manager shouldn't stop
+		c.Log.Error(err, "Fence agent catalog is incomplete")
+		return nil
+	}
+	c.Log.Info("Fence agent catalog is up to date", "agents", len(agents))
+	return nil
+}
+
+// syncFenceAgent creates or updates the FenceAgent object of the agent, and sets its status from the agent metadata
+func (c *FenceAgentCatalog) syncFenceAgent(ctx context.Context, agent string) error {
+	metadata, err := c.GetMetadata(agent)
+	if err != nil {
+		return err
+	}
+	schema, err := validation.ParseAgentMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	fenceAgent := &v1alpha1.FenceAgent{}
+	if err := c.Get(ctx, client.ObjectKey{Name: v1alpha1.FenceAgentName(agent)}, fenceAgent); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		fenceAgent = &v1alpha1.FenceAgent{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   v1alpha1.FenceAgentName(agent),
+				Labels: map[string]string{v1alpha1.FenceAgentCatalogLabel: ""},
+			},
+		}
+		if err := c.Create(ctx, fenceAgent); err != nil {
+			return err
+		}
+	}
+
+	fenceAgent.Status = buildFenceAgentStatus(agent, schema)
+	return c.Status().Update(ctx, fenceAgent)
+}
+
+// buildFenceAgentStatus converts the agent schema to the FenceAgent status
+func buildFenceAgentStatus(agent string, schema *validation.AgentSchema) v1alpha1.FenceAgentStatus {
+	now := metav1.Now()
+	status := v1alpha1.FenceAgentStatus{
+		Agent:          agent,
+		Description:    schema.Description,
+		Actions:        schema.Actions,
+		LastUpdateTime: &now,
+	}
+	for _, parameter := range schema.Parameters {
+		status.Parameters = append(status.Parameters, v1alpha1.FenceAgentParameter{
+			Name:          parameter.Name,
+			Options:       parameter.Options,
+			Type:          parameter.Type,
+			Required:      parameter.Required,
+			Default:       parameter.Default,
+			AllowedValues: parameter.AllowedValues,
+			Deprecated:    parameter.Deprecated,
+			Obsoletes:     parameter.Obsoletes,
+			Description:   parameter.Description,
+		})
+	}
+	return status
+}
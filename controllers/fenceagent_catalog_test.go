@@ -0,0 +1,124 @@
+/*
+Copyright 2023.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+const catalogAgentMetadata = `<?xml version="1.0" ?>
+<resource-agent name="fence_ipmilan" shortdesc="Fence agent for IPMI" >
+<parameters>
+	<parameter name="action" unique="0" required="1">
+		<getopt mixed="-o, --action=[action]" />
+		<content type="string" default="reboot"  />
+		<shortdesc lang="en">Fencing action</shortdesc>
+	</parameter>
+	<parameter name="ip" unique="0" required="1" obsoletes="ipaddr">
+		<getopt mixed="-a, --ip=[ip]" />
+		<content type="string"  />
+		<shortdesc lang="en">IP address or hostname of fencing device</shortdesc>
+	</parameter>
+</parameters>
+<actions>
+	<action name="on" automatic="0"/>
+	<action name="off" />
+	<action name="reboot" />
+	<action name="status" />
+</actions>
+</resource-agent>`
+
+var _ = Describe("FenceAgent Catalog", func() {
+	var (
+		agents  []string
+		catalog *FenceAgentCatalog
+	)
+
+	BeforeEach(func() {
+		agents = []string{fenceAgentIPMI}
+		catalog = &FenceAgentCatalog{
+			Client:     k8sClient,
+			Log:        log.WithName("fence agent catalog"),
+			ListAgents: func() ([]string, error) { return agents, nil },
+			GetMetadata: func(agent string) ([]byte, error) {
+				if agent == fenceAgentIPMI {
+					return []byte(catalogAgentMetadata), nil
+				}
+				return nil, fmt.Errorf("agent %s has no metadata", agent)
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &v1alpha1.FenceAgent{})).To(Succeed())
+	})
+
+	When("the catalog starts", func() {
+		It("should create a FenceAgent for each installed agent", func() {
+			Expect(catalog.Start(ctx)).To(Succeed())
+
+			fenceAgent := &v1alpha1.FenceAgent{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "fence-ipmilan"}, fenceAgent)).To(Succeed())
+			Expect(fenceAgent.Labels).To(HaveKey(v1alpha1.FenceAgentCatalogLabel))
+			Expect(fenceAgent.Status.Agent).To(Equal(fenceAgentIPMI))
+			Expect(fenceAgent.Status.Description).To(Equal("Fence agent for IPMI"))
+			Expect(fenceAgent.Status.Actions).To(Equal([]string{"on", "off", "reboot", "status"}))
+			Expect(fenceAgent.Status.LastUpdateTime).ToNot(BeNil())
+			Expect(fenceAgent.Status.Parameters).To(ConsistOf(
+				v1alpha1.FenceAgentParameter{Name: "action", Options: []string{"--action", "-o"}, Type: "string", Required: true, Default: "reboot", Description: "Fencing action"},
+				v1alpha1.FenceAgentParameter{Name: "ip", Options: []string{"--ip", "-a"}, Type: "string", Required: true, Obsoletes: "ipaddr", Description: "IP address or hostname of fencing device"},
+			))
+		})
+	})
+
+	When("an agent is no longer installed", func() {
+		BeforeEach(func() {
+			stale := &v1alpha1.FenceAgent{ObjectMeta: metav1.ObjectMeta{
+				Name:   "fence-stale",
+				Labels: map[string]string{v1alpha1.FenceAgentCatalogLabel: ""},
+			}}
+			Expect(k8sClient.Create(ctx, stale)).To(Succeed())
+		})
+
+		It("should delete its FenceAgent", func() {
+			Expect(catalog.Start(ctx)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "fence-ipmilan"}, &v1alpha1.FenceAgent{})).To(Succeed())
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: "fence-stale"}, &v1alpha1.FenceAgent{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the metadata of an agent can't be read", func() {
+		BeforeEach(func() {
+			agents = append(agents, "fence_broken")
+		})
+
+		It("should still create the FenceAgent of the other agents", func() {
+			Expect(catalog.Start(ctx)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "fence-ipmilan"}, &v1alpha1.FenceAgent{})).To(Succeed())
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: "fence-broken"}, &v1alpha1.FenceAgent{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -33,8 +33,11 @@ import (
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilErrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -43,6 +46,8 @@ import (
 
 	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
 	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+	"github.com/medik8s/fence-agents-remediation/pkg/metrics"
+	"github.com/medik8s/fence-agents-remediation/pkg/nodetemplate"
 	"github.com/medik8s/fence-agents-remediation/pkg/utils"
 )
 
@@ -56,8 +61,17 @@ const (
 	parameterActionName  = "--" + actionName
 	actionName           = "action"
 	parameterActionValue = "reboot"
+	// parameterOffActionValue and parameterOnActionValue are the fence agent actions which power off and power on the node
+	parameterOffActionValue = "off"
+	parameterOnActionValue  = "on"
+	// parameterStatusActionValue is the fence agent action which reports the node's power status
+	parameterStatusActionValue = "status"
+	// nodeRebootCheckInterval is the interval for checking again whether the fenced node has rebooted
+	nodeRebootCheckInterval = 10 * time.Second
 )
 
+var errUnsupportedRemediationStrategy = errors.New("unsupported remediation strategy")
+
 // FenceAgentsRemediationReconciler reconciles a FenceAgentsRemediation object
 type FenceAgentsRemediationReconciler struct {
 	client.Client
@@ -65,6 +79,17 @@ type FenceAgentsRemediationReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 	Executor *cli.Executer
+	// MaxConcurrentFencing is the maximal number, or percentage of the cluster nodes, of nodes which are fenced at the same time.
+	// The number of nodes isn't limited when it is nil
+	MaxConcurrentFencing *intstr.IntOrString
+	// StormThreshold is the number, or percentage of the cluster nodes, of unhealthy nodes above which new nodes aren't fenced.
+	// The remediation storm protection is disabled when it is nil
+	StormThreshold *intstr.IntOrString
+	// OutOfServiceTaintSupported is true when the cluster supports the out-of-service taint, which is used by the Automatic remediation strategy
+	OutOfServiceTaintSupported bool
+	// PodName is the name of the manager's own pod, i.e. its hostname. The fencing of the node which runs this pod is handed off to a
+	// manager on another node
+	PodName string
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -78,6 +103,10 @@ func (r *FenceAgentsRemediationReconciler) SetupWithManager(mgr ctrl.Manager) er
 // +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;delete;deletecollection
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch,namespace=system
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get,namespace=system
+// +kubebuilder:rbac:groups=machine.openshift.io,resources=machines,verbs=get
+// +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts,verbs=get
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;delete
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediations,verbs=get;list;watch;create;update;patch;delete
@@ -113,6 +142,9 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 		return emptyResult, err
 	}
 
+	// the duration of a successful remediation is observed only once its success is persisted, since a status update conflict
+	// repeats the reconcile which completes the remediation
+	var remediationDuration time.Duration
 	// At the end of each Reconcile we try to update CR's status
 	defer func() {
 		if updateErr := r.updateStatus(ctx, far); updateErr != nil {
@@ -120,6 +152,8 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 				r.Log.Info("Conflict has occurred on updating the CR status")
 			}
 			finalErr = utilErrors.NewAggregate([]error{updateErr, finalErr})
+		} else if remediationDuration > 0 {
+			metrics.ObserveRemediationDuration(remediationDuration)
 		}
 	}()
 
@@ -168,11 +202,25 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 			succeededCondition := meta.FindStatusCondition(far.Status.Conditions, commonConditions.SucceededType).Status
 			r.Log.Info("FAR didn't finish remediate the node ", "CR Name", req.Name, "processing condition", processingCondition,
 				"fenceAgentActionSucceeded condition", fenceAgentActionSucceededCondition, "succeeded condition", succeededCondition)
-			r.Executor.Remove(far.GetUID())
+			// keep a running power on of the node
+			if !meta.IsStatusConditionPresentAndEqual(far.Status.Conditions, utils.NodePoweredOnType, metav1.ConditionUnknown) {
+				r.Executor.Remove(far.GetUID())
+			}
+		}
+
+		// power on the node when it was left powered off by the fence agent
+		if isNodePowerOnRequired(far) {
+			if err := r.powerOnNode(ctx, far); err != nil {
+				r.Log.Error(err, "Failed to power on the node", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+				return emptyResult, err
+			}
+			r.Log.Info("Waiting for the node to be powered on before removing the taints", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+			return emptyResult, nil
 		}
 
 		// remove out-of-service taint when using OutOfServiceTaint remediation
-		if far.Spec.RemediationStrategy == v1alpha1.OutOfServiceTaintRemediationStrategy {
+		if far.Spec.RemediationStrategy == v1alpha1.OutOfServiceTaintRemediationStrategy ||
+			far.Status.RemediationStrategy == v1alpha1.OutOfServiceTaintRemediationStrategy {
 			r.Log.Info("Removing out-of-service taint", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
 			taint := utils.CreateOutOfServiceTaint()
 			if err := utils.RemoveTaint(r.Client, node.Name, taint); err != nil {
@@ -230,18 +278,101 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 			return emptyResult, nil
 		}
 
-		r.Log.Info("Build fence agent command line", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
-		faParams, isRetryRequired, err := r.buildFenceAgentParams(ctx, far)
-		if err != nil {
-			if !isRetryRequired {
-				return emptyResult, nil
+		// a node whose fence agent execution has started, possibly by a previous manager, has already passed the fencing limits
+		if far.Status.Execution == nil {
+			waitingReason, waitingMessage, err := r.getFencingWaitingReason(ctx, far)
+			if err != nil {
+				r.Log.Error(err, "Failed to check the fencing limits", "Node Name", node.Name)
+				return emptyResult, err
+			}
+			if waitingReason != "" {
+				if waitingCondition := meta.FindStatusCondition(far.Status.Conditions, utils.WaitingType); waitingCondition == nil ||
+					waitingCondition.Status != metav1.ConditionTrue || waitingCondition.Reason != string(waitingReason) {
+					eventReason := utils.EventReasonFencingQueued
+					switch waitingReason {
+					case utils.RemediationStormDetected:
+						eventReason = utils.EventReasonRemediationStorm
+					case utils.ControlPlaneQuorumProtected:
+						eventReason = utils.EventReasonQuorumProtected
+					case utils.FencingHandedOff:
+						eventReason = utils.EventReasonFencingHandedOff
+					}
+					commonEvents.WarningEvent(r.Recorder, far, eventReason, waitingMessage)
+				}
+				utils.UpdateConditions(waitingReason, far, r.Log)
+				return ctrl.Result{RequeueAfter: fencingWaitingRequeueInterval}, nil
+			}
+			// the admission is persisted before the fence agent is executed, since the executor updates the status as well
+			if meta.IsStatusConditionTrue(far.Status.Conditions, utils.WaitingType) {
+				r.Log.Info("The node isn't waiting to be fenced anymore", "Node Name", node.Name)
+				utils.UpdateConditions(utils.FencingAdmitted, far, r.Log)
+				return requeueImmediately, nil
 			}
-			return emptyResult, err
 		}
 
-		cmd := append([]string{far.Spec.Agent}, mapToSliceConvert(faParams)...)
-		r.Log.Info("Execute the fence agent", "Fence Agent", far.Spec.Agent, "Node Name", node.Name, "FAR uid", far.GetUID(), "Parameters", maps.Keys(faParams))
-		r.Executor.AsyncExecute(ctx, far.GetUID(), cmd, far.Spec.RetryCount, far.Spec.RetryInterval.Duration, far.Spec.Timeout.Duration)
+		// the fencing levels are executed from the first one, unless the execution of a previous manager is resumed
+		firstLevel, previousAttempts := 1, 0
+		if execution := far.Status.Execution; execution != nil && execution.Owner != r.Executor.Identity() {
+			if fencingLevel, isValid := getExecutionLevel(far, execution); isValid {
+				// the previous manager was restarted or lost its leadership while the fence agent was running
+				if waitTime := time.Until(execution.StartTime.Add(fencingLevel.Timeout.Duration)); waitTime > 0 {
+					r.Log.Info("Waiting for the fence agent attempt of a previous manager to time out", "Fence Agent", fencingLevel.Agent, "Node Name", node.Name,
+						"Previous Owner", execution.Owner, "Level", execution.Level, "Attempt", execution.Attempt, "Wait", waitTime)
+					return ctrl.Result{RequeueAfter: waitTime}, nil
+				}
+				isPoweredOff, err := r.isNodePoweredOffByExecution(ctx, far, fencingLevel)
+				if err != nil {
+					return emptyResult, err
+				}
+				if isPoweredOff {
+					r.completeExecution(far, execution, fencingLevel)
+					return emptyResult, nil
+				}
+				r.Log.Info("Resuming the fence agent execution of a previous manager", "Fence Agent", fencingLevel.Agent, "Node Name", node.Name,
+					"Previous Owner", execution.Owner, "Level", execution.Level, "Attempt", execution.Attempt)
+				firstLevel, previousAttempts = execution.Level, execution.Attempt-1
+				commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonFenceAgentResumed, fmt.Sprintf(utils.EventMessageFenceAgentResumed, execution.Level, execution.Attempt))
+			}
+		}
+
+		// the boot ID is recorded right before the node is fenced, and it is persisted before the fence agent is executed
+		if far.Spec.RebootVerification != nil && far.Status.Execution == nil && far.Status.PreFenceBootID != node.Status.NodeInfo.BootID {
+			r.Log.Info("Recording the node's boot ID before fencing", "Node Name", node.Name, "Boot ID", node.Status.NodeInfo.BootID)
+			far.Status.PreFenceBootID = node.Status.NodeInfo.BootID
+			return requeueImmediately, nil
+		}
+
+		r.Log.Info("Build fence agent command lines", "Fence Agent", far.Spec.Agent, "Fallback levels", len(far.Spec.FallbackLevels), "Node Name", node.Name)
+		fenceAction := getFenceAction(far.Spec.FencingMode)
+		var levels []cli.FencingLevel
+		for i, fencingLevel := range getFencingLevels(far) {
+			if i+1 < firstLevel {
+				continue
+			}
+			faParams, hasSecretParams, isRetryRequired, err := r.buildFenceAgentParams(ctx, far, fencingLevel)
+			if err != nil {
+				if !isRetryRequired {
+					return emptyResult, nil
+				}
+				return emptyResult, err
+			}
+			r.Log.Info("Fencing level parameters", "Level", i+1, "Fence Agent", fencingLevel.Agent, "Devices", len(fencingLevel.Devices), "Parameters", maps.Keys(faParams))
+			level, err := r.buildCLIFencingLevel(far, fencingLevel, faParams, hasSecretParams, fenceAction)
+			if err != nil {
+				// the fencing level is retried, since it might be fixed by updating the CR
+				r.Log.Error(err, "Failed to build the fence agent commands", "Level", i+1, "Fence Agent", fencingLevel.Agent, "Node Name", node.Name)
+				commonEvents.WarningEvent(r.Recorder, far, utils.EventReasonInvalidFencingLevel, fmt.Sprintf(utils.EventMessageInvalidFencingLevel, i+1, err))
+				return emptyResult, err
+			}
+			level.Number = i + 1
+			if level.Number == firstLevel {
+				level.PreviousAttempts = previousAttempts
+			}
+			levels = append(levels, level)
+		}
+
+		r.Log.Info("Execute the fence agent", "Fence Agent", far.Spec.Agent, "Node Name", node.Name, "FAR uid", far.GetUID())
+		r.Executor.AsyncExecute(ctx, far.GetUID(), levels)
 		commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
 		return emptyResult, nil
 	}
@@ -249,36 +380,74 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 	if meta.IsStatusConditionTrue(far.Status.Conditions, utils.FenceAgentActionSucceededType) &&
 		!meta.IsStatusConditionTrue(far.Status.Conditions, commonConditions.SucceededType) {
 		// Fence agent action succeeded
+		// - wait for the power status verification, if it is enabled
 		// - try to remove workloads
+		// - power on the node, if it was powered off in OffThenOn fencing mode
 		// - clean up Executor routine
 
-		switch far.Spec.RemediationStrategy {
-		case v1alpha1.ResourceDeletionRemediationStrategy, "":
-			// Basically RemediationStrategy should be set to ResourceDeletion strategy as the default strategy.
-			// However, it will be empty when the CS was created when ResourceDeletion strategy was the only strategy.
-			// In this case, the empty strategy should be treated as if ResourceDeletion strategy selected.
-			r.Log.Info("Remediation strategy is ResourceDeletion which explicitly deletes resources - manually deleting workload", "Node Name", req.Name)
-			commonEvents.NormalEvent(r.Recorder, node, utils.EventReasonDeleteResources, utils.EventMessageDeleteResources)
-			if err := commonResources.DeletePods(ctx, r.Client, node.Name); err != nil {
-				r.Log.Error(err, "Resource deletion has failed", "CR's Name", node.Name)
+		if far.Spec.Verification != nil && !meta.IsStatusConditionTrue(far.Status.Conditions, utils.FenceAgentVerifiedType) {
+			if meta.IsStatusConditionFalse(far.Status.Conditions, utils.FenceAgentVerifiedType) {
+				r.Log.Info("The node's power status couldn't be verified, thus its workloads won't be removed", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+				return emptyResult, nil
+			}
+			if !r.Executor.Exists(far.GetUID()) {
+				// the verification of a previous manager was interrupted, e.g. by a manager restart
+				if err := r.resumeVerification(ctx, far); err != nil {
+					r.Log.Error(err, "Failed to resume the power status verification", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+					return emptyResult, err
+				}
+			}
+			r.Log.Info("Waiting for the fence agent to verify the node's power status", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+			return emptyResult, nil
+		}
+
+		isOffThenOn := far.Spec.FencingMode == v1alpha1.OffThenOnFencingMode
+		// The workloads are removed once, and the removal is persisted before the remediation continues, since the following steps requeue
+		// the CR until the node is powered on or rebooted. In OffThenOn fencing mode the workloads have already been removed once the node
+		// is being powered on, also by a previous version which didn't record the removal.
+		if far.Status.WorkloadsRemovedTime == nil && (!isOffThenOn || meta.FindStatusCondition(far.Status.Conditions, utils.NodePoweredOnType) == nil) {
+			if err := r.removeWorkloads(ctx, far, node); err != nil {
+				if errors.Is(err, errUnsupportedRemediationStrategy) {
+					return emptyResult, nil
+				}
 				return emptyResult, err
 			}
-		case v1alpha1.OutOfServiceTaintRemediationStrategy:
-			r.Log.Info("Remediation strategy is OutOfServiceTaint which implicitly deletes resources - adding out-of-service taint", "Node Name", req.Name)
-			taintAdded, err := utils.AppendTaint(r.Client, node.Name, utils.CreateOutOfServiceTaint())
-			if err != nil {
-				r.Log.Error(err, "Failed to add out-of-service taint", "CR's Name", node.Name)
+			now := metav1.Now()
+			far.Status.WorkloadsRemovedTime = &now
+			return requeueImmediately, nil
+		}
+
+		if isOffThenOn && !meta.IsStatusConditionTrue(far.Status.Conditions, utils.NodePoweredOnType) {
+			if meta.IsStatusConditionFalse(far.Status.Conditions, utils.NodePoweredOnType) {
+				r.Log.Info("The node couldn't be powered on after its workloads were removed", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+				return emptyResult, nil
+			}
+			if err := r.powerOnNode(ctx, far); err != nil {
+				r.Log.Error(err, "Failed to power on the node", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
 				return emptyResult, err
-			} else if taintAdded {
-				r.Log.Info("out-of-service taint was added", "Node Name", node.Name)
-				commonEvents.NormalEvent(r.Recorder, node, utils.EventReasonAddOutOfServiceTaint, utils.EventMessageAddOutOfServiceTaint)
 			}
-		default:
-			// this should never happen since we enforce valid values with kubebuilder
-			err := errors.New("unsupported remediation strategy")
-			r.Log.Error(err, "Encountered unsupported remediation strategy. Please check template spec", "strategy", far.Spec.RemediationStrategy)
+			r.Log.Info("Waiting for the node to be powered on", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
 			return emptyResult, nil
 		}
+
+		if far.Spec.RebootVerification != nil && !meta.IsStatusConditionTrue(far.Status.Conditions, utils.NodeRebootVerifiedType) {
+			if meta.IsStatusConditionFalse(far.Status.Conditions, utils.NodeRebootVerifiedType) {
+				r.Log.Info("The node wasn't verified to have rebooted, thus the remediation can't succeed", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+				return emptyResult, nil
+			}
+			if waitTime := r.verifyNodeReboot(far, node); waitTime > 0 {
+				r.Log.Info("Waiting for the node to reboot", "Fence Agent", far.Spec.Agent, "Node Name", node.Name)
+				return ctrl.Result{RequeueAfter: waitTime}, nil
+			}
+			if !meta.IsStatusConditionTrue(far.Status.Conditions, utils.NodeRebootVerifiedType) {
+				return emptyResult, nil
+			}
+		}
+
+		if processingCondition := meta.FindStatusCondition(far.Status.Conditions, commonConditions.ProcessingType); processingCondition != nil && processingCondition.Status == metav1.ConditionTrue {
+			// the Processing condition has been true since the remediation has started
+			remediationDuration = time.Since(processingCondition.LastTransitionTime.Time)
+		}
 		utils.UpdateConditions(utils.RemediationFinishedSuccessfully, far, r.Log)
 
 		r.Executor.Remove(far.GetUID())
@@ -290,6 +459,402 @@ func (r *FenceAgentsRemediationReconciler) Reconcile(ctx context.Context, req ct
 	return emptyResult, nil
 }
 
+// removeWorkloads removes the workloads of the fenced node according to the remediation strategy
+func (r *FenceAgentsRemediationReconciler) removeWorkloads(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, node *corev1.Node) error {
+	if far.Status.RemediationStrategy == "" {
+		far.Status.RemediationStrategy = r.selectRemediationStrategy(far, node)
+	}
+	switch far.Status.RemediationStrategy {
+	case v1alpha1.ResourceDeletionRemediationStrategy, "":
+		// Basically RemediationStrategy should be set to ResourceDeletion strategy as the default strategy.
+		// However, it will be empty when the CS was created when ResourceDeletion strategy was the only strategy.
+		// In this case, the empty strategy should be treated as if ResourceDeletion strategy selected.
+		r.Log.Info("Remediation strategy is ResourceDeletion which explicitly deletes resources - manually deleting workload", "Node Name", node.Name)
+		commonEvents.NormalEvent(r.Recorder, node, utils.EventReasonDeleteResources, utils.EventMessageDeleteResources)
+		if err := r.deletePods(ctx, far, node); err != nil {
+			r.Log.Error(err, "Resource deletion has failed", "CR's Name", node.Name)
+			return err
+		}
+		if err := r.deleteVolumeAttachments(ctx, far, node); err != nil {
+			r.Log.Error(err, "Volume attachments deletion has failed", "CR's Name", node.Name)
+			return err
+		}
+	case v1alpha1.OutOfServiceTaintRemediationStrategy:
+		r.Log.Info("Remediation strategy is OutOfServiceTaint which implicitly deletes resources - adding out-of-service taint", "Node Name", node.Name)
+		taintAdded, err := utils.AppendTaint(r.Client, node.Name, utils.CreateOutOfServiceTaint())
+		if err != nil {
+			r.Log.Error(err, "Failed to add out-of-service taint", "CR's Name", node.Name)
+			return err
+		} else if taintAdded {
+			r.Log.Info("out-of-service taint was added", "Node Name", node.Name)
+			commonEvents.NormalEvent(r.Recorder, node, utils.EventReasonAddOutOfServiceTaint, utils.EventMessageAddOutOfServiceTaint)
+		}
+	default:
+		// this should never happen since we enforce valid values with kubebuilder
+		r.Log.Error(errUnsupportedRemediationStrategy, "Encountered unsupported remediation strategy. Please check template spec", "strategy", far.Spec.RemediationStrategy)
+		return errUnsupportedRemediationStrategy
+	}
+	return nil
+}
+
+// deletePods deletes the pods of the fenced node which are selected by the pod selection, or all of its pods when there is no pod selection
+func (r *FenceAgentsRemediationReconciler) deletePods(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, node *corev1.Node) error {
+	pods, err := utils.GetNodePods(ctx, r.Client, node.Name)
+	if err != nil {
+		return err
+	}
+	if far.Spec.PodSelection == nil {
+		if err := commonResources.DeletePods(ctx, r.Client, node.Name); err != nil {
+			return err
+		}
+		far.Status.DeletedPods += len(pods)
+		metrics.ObserveRemediationPodsDeleted(len(pods))
+		return nil
+	}
+
+	selectedPods, skippedPods, err := utils.SelectPods(pods, far.Spec.PodSelection)
+	if err != nil {
+		return err
+	}
+	deletedPods := 0
+	for i := range selectedPods {
+		pod := &selectedPods[i]
+		if err := r.Delete(ctx, pod, client.GracePeriodSeconds(0), client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to delete pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		deletedPods++
+	}
+	r.Log.Info("Pods were deleted according to the pod selection", "Node Name", node.Name, "Deleted Pods", deletedPods, "Skipped Pods", len(skippedPods))
+	far.Status.DeletedPods += deletedPods
+	far.Status.SkippedPods = len(skippedPods)
+	metrics.ObserveRemediationPodsDeleted(deletedPods)
+	return nil
+}
+
+// deleteVolumeAttachments deletes the volume attachments of the fenced node, since otherwise the volumes of the deleted stateful pods
+// can't be attached to their new node until the attach-detach controller gives up on detaching them from the fenced node
+func (r *FenceAgentsRemediationReconciler) deleteVolumeAttachments(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, node *corev1.Node) error {
+	volumeAttachments, err := utils.GetNodeVolumeAttachments(ctx, r.Client, node.Name)
+	if err != nil {
+		return err
+	}
+	for i := range volumeAttachments {
+		volumeAttachment := &volumeAttachments[i]
+		if !volumeAttachment.DeletionTimestamp.IsZero() {
+			// it was already deleted, and it is waiting for its finalizers
+			continue
+		}
+		if err := r.Delete(ctx, volumeAttachment); err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to delete volume attachment %s: %w", volumeAttachment.Name, err)
+		}
+		persistentVolumeName := ""
+		if volumeAttachment.Spec.Source.PersistentVolumeName != nil {
+			persistentVolumeName = *volumeAttachment.Spec.Source.PersistentVolumeName
+		}
+		r.Log.Info("Volume attachment was deleted", "Node Name", node.Name, "Volume Attachment", volumeAttachment.Name, "Persistent Volume", persistentVolumeName)
+		commonEvents.NormalEvent(r.Recorder, node, utils.EventReasonDeleteVolumeAttachment,
+			fmt.Sprintf(utils.EventMessageDeleteVolumeAttachment, volumeAttachment.Name, persistentVolumeName))
+		far.Status.DeletedVolumeAttachments++
+	}
+	return nil
+}
+
+// selectRemediationStrategy returns the remediation strategy of the spec, or the strategy which is selected for the node when the spec's
+// strategy is Automatic: OutOfServiceTaint when it is supported and the node has attached volumes, and ResourceDeletion otherwise
+func (r *FenceAgentsRemediationReconciler) selectRemediationStrategy(far *v1alpha1.FenceAgentsRemediation, node *corev1.Node) v1alpha1.RemediationStrategyType {
+	if far.Spec.RemediationStrategy == "" {
+		// the spec's strategy is empty when the CR was created when ResourceDeletion strategy was the only strategy
+		return v1alpha1.ResourceDeletionRemediationStrategy
+	}
+	if far.Spec.RemediationStrategy != v1alpha1.AutomaticRemediationStrategy {
+		return far.Spec.RemediationStrategy
+	}
+	strategy := v1alpha1.ResourceDeletionRemediationStrategy
+	if r.OutOfServiceTaintSupported && len(node.Status.VolumesAttached) > 0 {
+		strategy = v1alpha1.OutOfServiceTaintRemediationStrategy
+	}
+	r.Log.Info("Remediation strategy was selected automatically", "Node Name", node.Name, "Strategy", strategy,
+		"Out-of-Service Taint Supported", r.OutOfServiceTaintSupported, "Attached Volumes", len(node.Status.VolumesAttached))
+	commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonRemediationStrategy, fmt.Sprintf(utils.EventMessageRemediationStrategy, strategy))
+	return strategy
+}
+
+// verifyNodeReboot checks whether the node has rebooted since it was fenced, and updates the NodeRebootVerified condition accordingly.
+// It returns the time to wait before checking again, or zero once the reboot was verified or the reboot verification has timed out
+func (r *FenceAgentsRemediationReconciler) verifyNodeReboot(far *v1alpha1.FenceAgentsRemediation, node *corev1.Node) time.Duration {
+	fencedCondition := meta.FindStatusCondition(far.Status.Conditions, utils.FenceAgentActionSucceededType)
+	fenceTime := fencedCondition.LastTransitionTime.Time
+	if isNodeRebooted(far, node, fenceTime) {
+		r.Log.Info("The node has rebooted since it was fenced", "Node Name", node.Name, "Pre-Fence Boot ID", far.Status.PreFenceBootID,
+			"Boot ID", node.Status.NodeInfo.BootID)
+		utils.UpdateConditions(utils.NodeRebootConfirmed, far, r.Log)
+		commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonNodeRebootVerified, utils.EventMessageNodeRebootVerified)
+		return 0
+	}
+
+	waitTime := time.Until(fenceTime.Add(far.Spec.RebootVerification.Timeout.Duration))
+	if waitTime <= 0 {
+		r.Log.Info("The node wasn't verified to have rebooted within the reboot verification timeout", "Node Name", node.Name,
+			"Timeout", far.Spec.RebootVerification.Timeout.Duration)
+		utils.UpdateConditions(utils.NodeRebootTimedOut, far, r.Log)
+		commonEvents.WarningEvent(r.Recorder, far, utils.EventReasonNodeRebootNotVerified, utils.EventMessageNodeRebootNotVerified)
+		return 0
+	}
+	return min(waitTime, nodeRebootCheckInterval)
+}
+
+// isNodeRebooted checks whether the node's boot ID differs from the boot ID which was recorded before fencing, or whether the node
+// has become ready after it was fenced, e.g. when its boot ID is unknown
+func isNodeRebooted(far *v1alpha1.FenceAgentsRemediation, node *corev1.Node, fenceTime time.Time) bool {
+	if bootID := node.Status.NodeInfo.BootID; far.Status.PreFenceBootID != "" && bootID != "" && bootID != far.Status.PreFenceBootID {
+		return true
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue && condition.LastTransitionTime.Time.After(fenceTime)
+		}
+	}
+	return false
+}
+
+// powerOnNode runs the fence agent which powers on the node, unless it is already running
+func (r *FenceAgentsRemediationReconciler) powerOnNode(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) error {
+	if meta.FindStatusCondition(far.Status.Conditions, utils.NodePoweredOnType) == nil {
+		// the routine which has powered off the node is done, thus it can be cleaned up
+		r.Executor.Remove(far.GetUID())
+	} else if r.Executor.Exists(far.GetUID()) {
+		r.Log.Info("The node is already being powered on", "Fence Agent", far.Spec.Agent, "Node Name", getNodeName(far), "FAR uid", far.GetUID())
+		return nil
+	}
+
+	// power on the node with the fencing level which has powered it off
+	fencingLevel := getFencedByLevel(far)
+	faParams, hasSecretParams, _, err := r.buildFenceAgentParams(ctx, far, fencingLevel)
+	if err != nil {
+		return err
+	}
+	level, err := r.buildCLIFencingLevel(far, fencingLevel, faParams, hasSecretParams, parameterOnActionValue)
+	if err != nil {
+		return err
+	}
+	level.Number = getFencedByLevelNumber(far)
+	utils.UpdateConditions(utils.NodePowerOnStarted, far, r.Log)
+	r.Log.Info("Execute the fence agent to power on the node", "Fence Agent", fencingLevel.Agent, "Node Name", getNodeName(far), "FAR uid", far.GetUID())
+	r.Executor.AsyncPowerOn(ctx, far.GetUID(), level)
+	commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonPowerOnNode, utils.EventMessagePowerOnNode)
+	return nil
+}
+
+// isNodePowerOnRequired checks if the node was powered off by the fence agent, and it wasn't powered on afterward
+func isNodePowerOnRequired(far *v1alpha1.FenceAgentsRemediation) bool {
+	if getFenceAction(far.Spec.FencingMode) != parameterOffActionValue ||
+		!meta.IsStatusConditionTrue(far.Status.Conditions, utils.FenceAgentActionSucceededType) {
+		return false
+	}
+	powerOnCondition := meta.FindStatusCondition(far.Status.Conditions, utils.NodePoweredOnType)
+	return powerOnCondition == nil || powerOnCondition.Status == metav1.ConditionUnknown
+}
+
+// getFencingLevels returns the FAR's fencing levels in the order they should be tried,
+// where the first fencing level is built from the fence agent of the spec
+func getFencingLevels(far *v1alpha1.FenceAgentsRemediation) []v1alpha1.FencingLevel {
+	specLevel := v1alpha1.FencingLevel{
+		Agent:            far.Spec.Agent,
+		RetryCount:       far.Spec.RetryCount,
+		RetryInterval:    far.Spec.RetryInterval,
+		Timeout:          far.Spec.Timeout,
+		SharedParameters: far.Spec.SharedParameters,
+		NodeParameters:   far.Spec.NodeParameters,
+		NodeGroups:       far.Spec.NodeGroups,
+		ParametersSource: far.Spec.ParametersSource,
+		NodeSecretNames:  far.Spec.NodeSecretNames,
+		SharedSecretName: far.Spec.SharedSecretName,
+		ParametersFrom:   far.Spec.ParametersFrom,
+		Devices:          far.Spec.Devices,
+	}
+	return append([]v1alpha1.FencingLevel{specLevel}, far.Spec.FallbackLevels...)
+}
+
+// getFencedByLevel returns the fencing level which has fenced the node, or the first fencing level if it is unknown
+func getFencedByLevel(far *v1alpha1.FenceAgentsRemediation) v1alpha1.FencingLevel {
+	return getFencingLevels(far)[getFencedByLevelNumber(far)-1]
+}
+
+// getFencedByLevelNumber returns the number of the fencing level which has fenced the node, or 1 if it is unknown
+func getFencedByLevelNumber(far *v1alpha1.FenceAgentsRemediation) int {
+	if far.Status.FencedBy != nil && far.Status.FencedBy.Level > 0 && far.Status.FencedBy.Level <= len(getFencingLevels(far)) {
+		return far.Status.FencedBy.Level
+	}
+	return 1
+}
+
+// getExecutionLevel returns the fencing level of the fence agent execution, and whether the execution's fencing level exists
+func getExecutionLevel(far *v1alpha1.FenceAgentsRemediation, execution *v1alpha1.FenceAgentExecution) (v1alpha1.FencingLevel, bool) {
+	levels := getFencingLevels(far)
+	if execution.Level < 1 || execution.Level > len(levels) {
+		return v1alpha1.FencingLevel{}, false
+	}
+	return levels[execution.Level-1], true
+}
+
+// isNodePoweredOffByExecution checks whether the fence agent execution of a previous manager has already powered off the node,
+// in the fencing modes which power off the node, so it isn't fenced again.
+// A rebooted node can't be told apart from a node which wasn't fenced, thus it is always fenced again.
+func (r *FenceAgentsRemediationReconciler) isNodePoweredOffByExecution(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, fencingLevel v1alpha1.FencingLevel) (bool, error) {
+	if getFenceAction(far.Spec.FencingMode) != parameterOffActionValue {
+		return false, nil
+	}
+	faParams, hasSecretParams, _, err := r.buildFenceAgentParams(ctx, far, fencingLevel)
+	if err != nil {
+		return false, err
+	}
+	level, err := r.buildCLIFencingLevel(far, fencingLevel, faParams, hasSecretParams, parameterStatusActionValue)
+	if err != nil {
+		return false, err
+	}
+	// every fencing device has to be powered off
+	for _, command := range level.Commands {
+		powerStatus, err := r.Executor.GetPowerStatus(ctx, command, level.ParametersOverStdin, level.Timeout)
+		if err != nil || powerStatus != cli.PowerStatusOff {
+			r.Log.Info("The node wasn't powered off by the previous manager", "Fence Agent", fencingLevel.Agent, "Node Name", getNodeName(far),
+				"Power Status", powerStatus, "Error", err)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// completeExecution completes the fence agent execution of a previous manager which has already powered off the node
+func (r *FenceAgentsRemediationReconciler) completeExecution(far *v1alpha1.FenceAgentsRemediation, execution *v1alpha1.FenceAgentExecution, fencingLevel v1alpha1.FencingLevel) {
+	r.Log.Info("The node was already powered off by the fence agent execution of a previous manager", "Fence Agent", fencingLevel.Agent, "Node Name", getNodeName(far),
+		"Previous Owner", execution.Owner, "Level", execution.Level, "Attempt", execution.Attempt)
+	far.Status.FencedBy = &v1alpha1.FencingLevelStatus{Level: execution.Level, Agent: fencingLevel.Agent}
+	far.Status.Execution = nil
+	utils.UpdateConditions(utils.FenceAgentSucceeded, far, r.Log)
+	commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+	if far.Spec.Verification != nil {
+		// the fence agent has just reported the expected power status
+		utils.UpdateConditions(utils.FenceAgentVerificationSucceeded, far, r.Log)
+		commonEvents.NormalEvent(r.Recorder, far, utils.EventReasonFenceAgentVerified, utils.EventMessageFenceAgentVerified)
+	}
+}
+
+// resumeVerification runs the power status verification of the fencing level which has fenced the node
+func (r *FenceAgentsRemediationReconciler) resumeVerification(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) error {
+	fencingLevel := getFencedByLevel(far)
+	faParams, hasSecretParams, _, err := r.buildFenceAgentParams(ctx, far, fencingLevel)
+	if err != nil {
+		return err
+	}
+	level, err := r.buildCLIFencingLevel(far, fencingLevel, faParams, hasSecretParams, getFenceAction(far.Spec.FencingMode))
+	if err != nil {
+		return err
+	}
+	r.Log.Info("Resuming the power status verification", "Fence Agent", fencingLevel.Agent, "Node Name", getNodeName(far), "FAR uid", far.GetUID())
+	r.Executor.AsyncVerify(ctx, far.GetUID(), level.Verification)
+	return nil
+}
+
+// buildCLIFencingLevel builds the fencing level command lines with the given action, and its power status verification.
+// When a fencing level with several devices reboots the node, all the devices are powered off, and only then they are powered on.
+func (r *FenceAgentsRemediationReconciler) buildCLIFencingLevel(far *v1alpha1.FenceAgentsRemediation, fencingLevel v1alpha1.FencingLevel, fenceAgentParams map[v1alpha1.ParameterName]string,
+	hasSecretParams bool, action string) (cli.FencingLevel, error) {
+	devicesParams, err := r.buildDevicesParams(far, fencingLevel, fenceAgentParams)
+	if err != nil {
+		return cli.FencingLevel{}, err
+	}
+
+	parametersOverStdin := isParametersOverStdin(far.Spec.ParametersTransport, hasSecretParams)
+	level := cli.FencingLevel{
+		RetryCount:          fencingLevel.RetryCount,
+		RetryInterval:       fencingLevel.RetryInterval.Duration,
+		Timeout:             fencingLevel.Timeout.Duration,
+		ParametersOverStdin: parametersOverStdin,
+		Verification:        buildVerification(far, fencingLevel.Agent, devicesParams, parametersOverStdin, getExpectedPowerStatus(action)),
+	}
+	if len(devicesParams) > 1 && action == parameterActionValue {
+		level.Commands = buildFenceAgentCommands(fencingLevel.Agent, devicesParams, parameterOffActionValue)
+		level.PowerOnCommands = buildFenceAgentCommands(fencingLevel.Agent, devicesParams, parameterOnActionValue)
+	} else {
+		level.Commands = buildFenceAgentCommands(fencingLevel.Agent, devicesParams, action)
+	}
+	return level, nil
+}
+
+// buildDevicesParams builds the parameters of each fencing device of the fencing level, on top of the fencing level's parameters,
+// or returns the fencing level's parameters as a single device if the fencing level has no devices
+func (r *FenceAgentsRemediationReconciler) buildDevicesParams(far *v1alpha1.FenceAgentsRemediation, fencingLevel v1alpha1.FencingLevel, fenceAgentParams map[v1alpha1.ParameterName]string) ([]map[v1alpha1.ParameterName]string, error) {
+	if len(fencingLevel.Devices) == 0 {
+		return []map[v1alpha1.ParameterName]string{fenceAgentParams}, nil
+	}
+
+	nodeName := v1alpha1.NodeName(getNodeName(far))
+	fenceAction := getFenceAction(far.Spec.FencingMode)
+	devicesParams := make([]map[v1alpha1.ParameterName]string, 0, len(fencingLevel.Devices))
+	for _, device := range fencingLevel.Devices {
+		deviceParams := maps.Clone(fenceAgentParams)
+		// device parameters override the fencing level's parameters, and device node parameters override the device shared parameters
+		for paramName, paramVal := range device.SharedParameters {
+			if err := validateFenceAction(paramName, paramVal, fenceAction, r.Log); err != nil {
+				return nil, err
+			}
+			deviceParams[paramName] = paramVal
+		}
+		for paramName, nodeMap := range device.NodeParameters {
+			if nodeVal, isFound := nodeMap[nodeName]; isFound {
+				if err := validateFenceAction(paramName, nodeVal, fenceAction, r.Log); err != nil {
+					return nil, err
+				}
+				deviceParams[paramName] = nodeVal
+			}
+		}
+		devicesParams = append(devicesParams, deviceParams)
+	}
+	return devicesParams, nil
+}
+
+// getFenceAction returns the fence agent action which fences the node in the given fencing mode
+func getFenceAction(fencingMode v1alpha1.FencingModeType) string {
+	switch fencingMode {
+	case v1alpha1.OffThenOnFencingMode, v1alpha1.OffOnlyFencingMode:
+		return parameterOffActionValue
+	default:
+		// Reboot is the default fencing mode, and the fencing mode is empty for CRs which were created before fencing modes were introduced
+		return parameterActionValue
+	}
+}
+
+// getExpectedPowerStatus returns the power status which the node is expected to have after the fence agent action
+func getExpectedPowerStatus(action string) string {
+	if action == parameterOffActionValue {
+		return cli.PowerStatusOff
+	}
+	return cli.PowerStatusOn
+}
+
+// buildFenceAgentCommand builds the fence agent command line with the given action
+func buildFenceAgentCommand(agent string, fenceAgentParams map[v1alpha1.ParameterName]string, action string) []string {
+	params := maps.Clone(fenceAgentParams)
+	delete(params, actionName)
+	params[parameterActionName] = action
+	return append([]string{agent}, mapToSliceConvert(params)...)
+}
+
+// buildFenceAgentCommands builds the fence agent command line with the given action for each fencing device
+func buildFenceAgentCommands(agent string, devicesParams []map[v1alpha1.ParameterName]string, action string) [][]string {
+	commands := make([][]string, 0, len(devicesParams))
+	for _, deviceParams := range devicesParams {
+		commands = append(commands, buildFenceAgentCommand(agent, deviceParams, action))
+	}
+	return commands
+}
+
 // mapToSliceConvert converts param value map to slice
 func mapToSliceConvert(fenceAgentParams map[v1alpha1.ParameterName]string) []string {
 	fenceAgentParamsSlice := make([]string, 0, len(fenceAgentParams))
@@ -299,6 +864,33 @@ func mapToSliceConvert(fenceAgentParams map[v1alpha1.ParameterName]string) []str
 	return fenceAgentParamsSlice
 }
 
+// buildVerification builds the fence agent status commands for verifying the node's power status, or returns nil when verification is disabled
+func buildVerification(far *v1alpha1.FenceAgentsRemediation, agent string, devicesParams []map[v1alpha1.ParameterName]string, parametersOverStdin bool, expectedStatus string) *cli.Verification {
+	if far.Spec.Verification == nil {
+		return nil
+	}
+	return &cli.Verification{
+		Commands:            buildFenceAgentCommands(agent, devicesParams, parameterStatusActionValue),
+		ExpectedStatus:      expectedStatus,
+		Timeout:             far.Spec.Verification.Timeout.Duration,
+		Interval:            far.Spec.Verification.Interval.Duration,
+		ParametersOverStdin: parametersOverStdin,
+	}
+}
+
+// isParametersOverStdin checks if the fence agent parameters should be passed over stdin, based on the parameters transport
+func isParametersOverStdin(parametersTransport v1alpha1.ParametersTransportType, hasSecretParams bool) bool {
+	switch parametersTransport {
+	case v1alpha1.StdinParametersTransport:
+		return true
+	case v1alpha1.CommandLineParametersTransport:
+		return false
+	default:
+		// SecretsOverStdin is the default parameters transport, and it is empty for CRs which were created before it was introduced
+		return hasSecretParams
+	}
+}
+
 // isTimedOutByNHC checks if NHC set a timeout annotation on the CR
 func isTimedOutByNHC(far *v1alpha1.FenceAgentsRemediation) bool {
 	if far != nil && far.Annotations != nil && far.DeletionTimestamp == nil {
@@ -341,20 +933,29 @@ func (r *FenceAgentsRemediationReconciler) updateStatus(ctx context.Context, far
 	return nil
 }
 
-// collectRemediationSecretParams collects the parameters from the shared secret and the node secret
-func (r *FenceAgentsRemediationReconciler) collectRemediationSecretParams(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) (map[string]string, error) {
+// collectRemediationSecretParams collects the parameters of the fencing level from the shared secret, the node group secret and the node secret
+func (r *FenceAgentsRemediationReconciler) collectRemediationSecretParams(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, fencingLevel v1alpha1.FencingLevel,
+	nodeGroup *v1alpha1.NodeGroup) (map[string]string, error) {
 	secretParams := map[string]string{}
 	var err error
 
 	// collect secret params from shared secret
-	if far.Spec.SharedSecretName != nil {
-		secretParams, err = r.collectSecretParams(ctx, *far.Spec.SharedSecretName, far.Namespace)
+	if fencingLevel.SharedSecretName != nil {
+		secretParams, err = r.collectSecretParams(ctx, *fencingLevel.SharedSecretName, far.Namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// collect secret params from the node group's secret, in case param exist both in shared and node group, node group param will override the shared.
+	if nodeGroup != nil && nodeGroup.SecretName != nil {
+		nodeGroupSecretParams, err := r.collectSecretParams(ctx, *nodeGroup.SecretName, far.Namespace)
 		if err != nil {
 			return nil, err
 		}
+		maps.Copy(secretParams, nodeGroupSecretParams)
 	}
 	// collect secret params from the node's secret
-	nodeSecretName, isFound := far.Spec.NodeSecretNames[v1alpha1.NodeName(getNodeName(far))]
+	nodeSecretName, isFound := fencingLevel.NodeSecretNames[v1alpha1.NodeName(getNodeName(far))]
 	var nodeSecretParams map[string]string
 	if isFound {
 		nodeSecretParams, err = r.collectSecretParams(ctx, nodeSecretName, far.Namespace)
@@ -384,6 +985,80 @@ func (r *FenceAgentsRemediationReconciler) collectSecretParams(ctx context.Conte
 	return secretParams, nil
 }
 
+// collectReferencedParams reads the values of the parameters which refer to keys of Secrets or ConfigMaps, and returns whether any of them
+// comes from a Secret. Missing Secrets, ConfigMaps or keys are skipped when the reference is optional, otherwise they return an error.
+func (r *FenceAgentsRemediationReconciler) collectReferencedParams(ctx context.Context, parametersFrom map[v1alpha1.ParameterName]v1alpha1.ParameterValueSource,
+	namespace string) (map[v1alpha1.ParameterName]string, bool, error) {
+	referencedParams := make(map[v1alpha1.ParameterName]string)
+	hasSecretParams := false
+	for paramName, valueSource := range parametersFrom {
+		var data map[string]string
+		var kind, name, key string
+		var optional *bool
+		switch {
+		case valueSource.SecretKeyRef != nil:
+			kind, name, key, optional = "Secret", valueSource.SecretKeyRef.Name, valueSource.SecretKeyRef.Key, valueSource.SecretKeyRef.Optional
+			secret, err := r.getSecret(ctx, client.ObjectKey{Name: name, Namespace: namespace})
+			if err != nil {
+				return nil, false, fmt.Errorf(errorFailGettingSecret, name, namespace, err)
+			}
+			if secret != nil {
+				data = make(map[string]string, len(secret.Data))
+				for secretKey, secretVal := range secret.Data {
+					data[secretKey] = string(secretVal)
+				}
+			}
+		case valueSource.ConfigMapKeyRef != nil:
+			kind, name, key, optional = "ConfigMap", valueSource.ConfigMapKeyRef.Name, valueSource.ConfigMapKeyRef.Key, valueSource.ConfigMapKeyRef.Optional
+			// the ConfigMap is read as an unstructured object, i.e. directly from the API server, since caching the ConfigMaps would watch all of them
+			configMap := &unstructured.Unstructured{}
+			configMap.SetGroupVersionKind(configMapGVK)
+			if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, configMap); err != nil {
+				if !apiErrors.IsNotFound(err) {
+					return nil, false, fmt.Errorf("failed to get ConfigMap `%s` at namespace `%s`: %w", name, namespace, err)
+				}
+			} else {
+				data, _, _ = unstructured.NestedStringMap(configMap.Object, "data")
+			}
+		default:
+			// this should never happen since we enforce a single reference with the webhook
+			return nil, false, fmt.Errorf("parameter %s has no Secret or ConfigMap key reference", paramName)
+		}
+
+		value, isFound := data[key]
+		if !isFound {
+			if optional != nil && *optional {
+				r.Log.Info("optional parameter reference is missing", "parameter name", paramName, "kind", kind, "name", name, "key", key)
+				continue
+			}
+			return nil, false, fmt.Errorf("key `%s` of %s `%s` at namespace `%s` of parameter %s is missing", key, kind, name, namespace, paramName)
+		}
+		referencedParams[paramName] = value
+		hasSecretParams = hasSecretParams || valueSource.SecretKeyRef != nil
+		r.Log.Info("found a value from parameter reference", "parameter name", paramName, "kind", kind, "name", name, "key", key)
+	}
+	return referencedParams, hasSecretParams, nil
+}
+
+// getNodeGroup returns the node group of the fencing level which selects the node, or nil if no node group selects it
+func getNodeGroup(fencingLevel v1alpha1.FencingLevel, node *corev1.Node) (*v1alpha1.NodeGroup, error) {
+	var nodeGroup *v1alpha1.NodeGroup
+	for i := range fencingLevel.NodeGroups {
+		selector, err := metav1.LabelSelectorAsSelector(&fencingLevel.NodeGroups[i].NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector of node group %s: %w", fencingLevel.NodeGroups[i].Name, err)
+		}
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if nodeGroup != nil {
+			return nil, fmt.Errorf("node %s is selected by both node groups %s and %s", node.Name, nodeGroup.Name, fencingLevel.NodeGroups[i].Name)
+		}
+		nodeGroup = &fencingLevel.NodeGroups[i]
+	}
+	return nodeGroup, nil
+}
+
 // getNodeName checks for the node name in far's commonAnnotations.NodeNameAnnotation if it does not exist it assumes the node name equals to far CR's name and return it.
 func getNodeName(far *v1alpha1.FenceAgentsRemediation) string {
 	ann := far.GetAnnotations()
@@ -408,39 +1083,85 @@ func (r *FenceAgentsRemediationReconciler) getSecret(ctx context.Context, secret
 	return secret, nil
 }
 
-// buildFenceAgentParams collects the FAR's parameters for the node based on FAR CR, and if the CR is missing parameters
-// or the CR's name don't match nodeParameter name, or it has an action which is different from reboot, then return an error
-func (r *FenceAgentsRemediationReconciler) buildFenceAgentParams(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) (map[v1alpha1.ParameterName]string, bool, error) {
+// buildFenceAgentParams collects the fencing level's parameters for the node based on FAR CR, and if the fencing level is missing parameters
+// or the CR's name don't match nodeParameter name, or it has an action which is different from the fencing mode action, then return an error.
+// It also returns whether any of the parameters comes from a Secret.
+func (r *FenceAgentsRemediationReconciler) buildFenceAgentParams(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, fencingLevel v1alpha1.FencingLevel) (map[v1alpha1.ParameterName]string, bool, bool, error) {
 	nodeName := getNodeName(far)
-	secretParams, err := r.collectRemediationSecretParams(ctx, far)
+	// the node is needed for selecting its node group, for deriving the parameters of its parameters source, and for resolving the parameter templates
+	var node *corev1.Node
+	var nodeGroup *v1alpha1.NodeGroup
+	if len(fencingLevel.NodeGroups) > 0 || fencingLevel.ParametersSource != "" {
+		node = &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+			r.Log.Error(err, "Failed to get the node for selecting its node group or parameters", "Node Name", nodeName, "CR Name", far.Name)
+			return nil, false, true, err
+		}
+	}
+	if len(fencingLevel.NodeGroups) > 0 {
+		var err error
+		if nodeGroup, err = getNodeGroup(fencingLevel, node); err != nil {
+			r.Log.Error(err, "Failed selecting the node group", "Node Name", nodeName, "CR Name", far.Name)
+			return nil, false, false, err
+		}
+	}
+
+	sourceParams, hasSourceSecretParams, isRetryRequired, err := r.collectSourceParams(ctx, fencingLevel, node)
+	if err != nil {
+		r.Log.Error(err, "Failed deriving the parameters of the parameters source", "Node Name", nodeName, "CR Name", far.Name,
+			"Parameters Source", fencingLevel.ParametersSource)
+		return nil, false, isRetryRequired, err
+	}
+
+	secretParams, err := r.collectRemediationSecretParams(ctx, far, fencingLevel, nodeGroup)
 	if err != nil {
 		r.Log.Error(err, "Failed collecting secrets data", "Node Name", nodeName, "CR Name", far.Name)
-		return nil, true, err
+		return nil, false, true, err
+	}
+	referencedParams, hasReferencedSecretParams, err := r.collectReferencedParams(ctx, fencingLevel.ParametersFrom, far.Namespace)
+	if err != nil {
+		r.Log.Error(err, "Failed collecting referenced parameters", "Node Name", nodeName, "CR Name", far.Name)
+		return nil, false, true, err
 	}
 
 	fenceAgentParams := make(map[v1alpha1.ParameterName]string)
+	fenceAction := getFenceAction(far.Spec.FencingMode)
 
 	// append shared parameters
-	for paramName, paramVal := range far.Spec.SharedParameters {
-		// Verify action must be reboot
-		if err := validateRebootAction(paramName, paramVal, r.Log); err != nil {
-			return nil, false, err
+	for paramName, paramVal := range fencingLevel.SharedParameters {
+		// Verify action must match the fencing mode
+		if err := validateFenceAction(paramName, paramVal, fenceAction, r.Log); err != nil {
+			return nil, false, false, err
 		}
 		// Verify param isn't already defined
 		if err := validateUniqueParam(fenceAgentParams, paramName, r.Log); err != nil {
-			return nil, false, err
+			return nil, false, false, err
 		}
 		fenceAgentParams[paramName] = paramVal
 	}
 
+	// append node group parameters
+	if nodeGroup != nil {
+		for paramName, paramVal := range nodeGroup.Parameters {
+			if err := validateFenceAction(paramName, paramVal, fenceAction, r.Log); err != nil {
+				return nil, false, false, err
+			}
+			// node group param value overrides shared param
+			if _, exist := fenceAgentParams[paramName]; exist {
+				r.Log.Info("Shared parameter is overridden by node group parameter", "parameter", paramName, "node group", nodeGroup.Name)
+			}
+			fenceAgentParams[paramName] = paramVal
+		}
+	}
+
 	// append node parameters
-	for paramName, nodeMap := range far.Spec.NodeParameters {
+	for paramName, nodeMap := range fencingLevel.NodeParameters {
 		if nodeVal, isFound := nodeMap[v1alpha1.NodeName(nodeName)]; isFound {
-			// Verify action must be reboot
-			if err := validateRebootAction(paramName, nodeVal, r.Log); err != nil {
-				return nil, false, err
+			// Verify action must match the fencing mode
+			if err := validateFenceAction(paramName, nodeVal, fenceAction, r.Log); err != nil {
+				return nil, false, false, err
 			}
-			// For node params we don't enforce uniqueness node param value will override shared param
+			// For node params we don't enforce uniqueness node param value will override shared and node group params
 			if _, exist := fenceAgentParams[paramName]; exist {
 				r.Log.Info("Shared parameter is overridden by node parameter", "parameter", paramName)
 			}
@@ -451,38 +1172,79 @@ func (r *FenceAgentsRemediationReconciler) buildFenceAgentParams(ctx context.Con
 		}
 	}
 
+	// resolve the parameter templates against the node, and retry when the node lacks a field which a template refers to, since it might be added later
+	for paramName, paramVal := range fenceAgentParams {
+		if !nodetemplate.IsTemplate(paramVal) {
+			continue
+		}
+		if node == nil {
+			node = &corev1.Node{}
+			if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+				r.Log.Error(err, "Failed to get the node for resolving the parameter templates", "Node Name", nodeName, "CR Name", far.Name)
+				return nil, false, true, err
+			}
+		}
+		resolvedVal, err := nodetemplate.Resolve(paramVal, node)
+		if err != nil {
+			err = fmt.Errorf("failed to resolve the template of parameter %s: %w", paramName, err)
+			r.Log.Error(err, "Failed resolving parameter template", "Node Name", nodeName, "CR Name", far.Name)
+			return nil, false, true, err
+		}
+		fenceAgentParams[paramName] = resolvedVal
+	}
+
 	// append secret parameters
 	for secretKey, secretVal := range secretParams {
 		secretParam := v1alpha1.ParameterName(secretKey)
-		// Verify action must be reboot
-		if err := validateRebootAction(secretParam, secretVal, r.Log); err != nil {
-			return nil, false, err
+		// Verify action must match the fencing mode
+		if err := validateFenceAction(secretParam, secretVal, fenceAction, r.Log); err != nil {
+			return nil, false, false, err
 		}
 		if err := validateUniqueParam(fenceAgentParams, secretParam, r.Log); err != nil {
-			return nil, false, err
+			return nil, false, false, err
 		}
 		fenceAgentParams[secretParam] = secretVal
 	}
 
+	// append the parameters which refer to keys of Secrets or ConfigMaps
+	for paramName, paramVal := range referencedParams {
+		if err := validateFenceAction(paramName, paramVal, fenceAction, r.Log); err != nil {
+			return nil, false, false, err
+		}
+		if err := validateUniqueParam(fenceAgentParams, paramName, r.Log); err != nil {
+			return nil, false, false, err
+		}
+		fenceAgentParams[paramName] = paramVal
+	}
+
+	// append the parameters of the parameters source, which are overridden by any other parameter
+	for paramName, paramVal := range sourceParams {
+		if _, exist := fenceAgentParams[paramName]; exist {
+			r.Log.Info("Parameter of the parameters source is overridden", "parameter", paramName, "parameters source", fencingLevel.ParametersSource)
+			continue
+		}
+		fenceAgentParams[paramName] = paramVal
+	}
+
 	if len(fenceAgentParams) == 0 {
 		err := errors.New(errorMissingParams)
 		r.Log.Error(err, "Missing parameters")
-		return nil, false, err
+		return nil, false, false, err
 	}
 
-	// Add the reboot action with its default value - https://github.com/ClusterLabs/fence-agents/blob/main/lib/fencing.py.py#L103
+	// Add the fencing mode action, which is reboot by default - https://github.com/ClusterLabs/fence-agents/blob/main/lib/fencing.py.py#L103
 	if _, exist := fenceAgentParams[parameterActionName]; !exist {
-		r.Log.Info("`action` parameter is missing, so we add it with the default value of `reboot`")
-		fenceAgentParams[parameterActionName] = parameterActionValue
+		r.Log.Info("`action` parameter is missing, so we add it with the value of the fencing mode", "action", fenceAction)
+		fenceAgentParams[parameterActionName] = fenceAction
 	}
 
-	return fenceAgentParams, false, nil
+	return fenceAgentParams, len(secretParams) > 0 || hasReferencedSecretParams || hasSourceSecretParams, false, nil
 }
 
-func validateRebootAction(paramName v1alpha1.ParameterName, paramVal string, logger logr.Logger) error {
-	if (paramName == actionName || paramName == parameterActionName) && paramVal != parameterActionValue {
-		// --action parameter with a different value from reboot is not supported
-		err := errors.New("FAR doesn't support any other action than reboot")
+func validateFenceAction(paramName v1alpha1.ParameterName, paramVal, fenceAction string, logger logr.Logger) error {
+	if (paramName == actionName || paramName == parameterActionName) && paramVal != fenceAction {
+		// --action parameter with a different value from the fencing mode action is not supported
+		err := fmt.Errorf("FAR doesn't support any other action than %s in this fencing mode", fenceAction)
 		logger.Error(err, "can't build CR with this action attribute", "action", paramVal)
 		return err
 	}
@@ -21,19 +21,25 @@ import (
 	"time"
 
 	commonConditions "github.com/medik8s/common/pkg/conditions"
+	medik8sLabels "github.com/medik8s/common/pkg/labels"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
 	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+	"github.com/medik8s/fence-agents-remediation/pkg/metrics"
 	"github.com/medik8s/fence-agents-remediation/pkg/utils"
 )
 
@@ -99,6 +105,9 @@ var _ = Describe("FAR Controller", func() {
 	}
 	BeforeEach(func() {
 		storedCommand = storedCommand[:0]
+		storedStatusCommand = storedStatusCommand[:0]
+		storedCommands = nil
+		storedOverStdin = false
 	})
 
 	Context("Reconcile with ResourceDeletion strategy", func() {
@@ -113,7 +122,7 @@ var _ = Describe("FAR Controller", func() {
 			DeferCleanup(cleanupTestedResources, testPod)
 
 			farPod := createRunningPod("far-manager-test", farPodName, "")
-			DeferCleanup(k8sClient.Delete, context.Background(), farPod)
+			DeferCleanup(cleanupTestedResources, farPod)
 
 			nodeSecret = generateSecret(nodeSecretName, map[string][]byte{
 				"--pass":  []byte("abc"),
@@ -316,88 +325,1217 @@ var _ = Describe("FAR Controller", func() {
 				})
 
 			})
+			When("The node is selected by a node group", func() {
+				BeforeEach(func() {
+					node.Labels = map[string]string{"rack": "a"}
+					groupSecret := generateSecret("fence-agents-credentials-rack-a", map[string][]byte{
+						"--mock-secure-param-a": []byte("mock-top-secret-group-value"),
+					})
+					Expect(k8sClient.Create(context.Background(), groupSecret)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), groupSecret)
+
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					groupSecretName := groupSecret.Name
+					underTestFAR.Spec.NodeGroups = []v1alpha1.NodeGroup{
+						{
+							Name:         "rack-a",
+							NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}},
+							Parameters:   map[v1alpha1.ParameterName]string{"--ip": "192.168.111.2", "--ipport": "600"},
+							SecretName:   &groupSecretName,
+						},
+						{
+							Name:         "rack-b",
+							NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"rack": "b"}},
+							Parameters:   map[v1alpha1.ParameterName]string{"--ip": "192.168.111.3"},
+						},
+					}
+				})
+				It("Node group params should override shared params, while node params override them", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ConsistOf([]string{
+							"fence_ipmilan",
+							"--lanplus",
+							"--password=password",
+							"--username=admin",
+							"--action=reboot",
+							"--ip=192.168.111.2",
+							"--mock-secure-param-a=mock-top-secret-group-value",
+							"--pass2=abc2",
+							"--pass=abc",
+							"--ipport=6233"}))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				})
+			})
+			When("Param values are node templates", func() {
+				templateShareParam := map[v1alpha1.ParameterName]string{
+					"--username": "admin",
+					"--password": "password",
+					"--ip":       `{{ .Node.Annotations["bmc.example.com/address"] }}`,
+					"--ipport":   `{{ .Node.Labels["bmc.example.com/port"] }}`,
+					"--lanplus":  "",
+				}
+				BeforeEach(func() {
+					node.Annotations = map[string]string{"bmc.example.com/address": "192.168.111.1"}
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, templateShareParam, nil, v1alpha1.ResourceDeletionRemediationStrategy)
+				})
+				When("the node has the fields of the templates", func() {
+					BeforeEach(func() {
+						node.Labels = map[string]string{"bmc.example.com/port": "6233"}
+					})
+					It("should resolve the templates against the node", func() {
+						Eventually(func(g Gomega) {
+							g.Expect(storedCommand).To(ConsistOf([]string{
+								"fence_ipmilan",
+								"--lanplus",
+								"--password=password",
+								"--username=admin",
+								"--action=reboot",
+								"--ip=192.168.111.1",
+								"--pass2=abc2",
+								"--pass=abc",
+								"--ipport=6233"}))
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					})
+				})
+				When("the node lacks a field of a template", func() {
+					It("A template error would prevent execution of fence agent command", func() {
+						Consistently(func(g Gomega) {
+							g.Expect(storedCommand).To(BeEmpty())
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+						verifyEvent(corev1.EventTypeNormal, utils.EventReasonAddRemediationTaint, utils.EventMessageAddRemediationTaint)
+						verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+					})
+				})
+			})
+			When("Params are derived from the BareMetalHost of the node", func() {
+				BeforeEach(func() {
+					bmcSecret := generateSecret("worker-0-bmc-secret", map[string][]byte{
+						"username": []byte("bmc-admin"),
+						"password": []byte("bmc-password"),
+					})
+					Expect(k8sClient.Create(context.Background(), bmcSecret)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), bmcSecret)
+
+					machine := &unstructured.Unstructured{}
+					machine.SetGroupVersionKind(machineGVK)
+					machine.SetName("worker-0-machine")
+					machine.SetNamespace(defaultNamespace)
+					machine.SetAnnotations(map[string]string{bareMetalHostAnnotation: defaultNamespace + "/worker-0-bmh"})
+					Expect(k8sClient.Create(context.Background(), machine)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), machine)
+
+					bmh := &unstructured.Unstructured{}
+					bmh.SetGroupVersionKind(bareMetalHostGVK)
+					bmh.SetName("worker-0-bmh")
+					bmh.SetNamespace(defaultNamespace)
+					Expect(unstructured.SetNestedStringMap(bmh.Object, map[string]string{
+						"address":         "ipmi://192.168.111.1:6233",
+						"credentialsName": bmcSecret.Name,
+					}, "spec", "bmc")).To(Succeed())
+					Expect(k8sClient.Create(context.Background(), bmh)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), bmh)
+
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, map[v1alpha1.ParameterName]string{"--ipport": "6234"}, nil,
+						v1alpha1.ResourceDeletionRemediationStrategy)
+					underTestFAR.Spec.ParametersSource = v1alpha1.BareMetalHostParametersSource
+				})
+				When("the node refers to its Machine", func() {
+					BeforeEach(func() {
+						node.Annotations = map[string]string{machineAnnotation: defaultNamespace + "/worker-0-machine"}
+					})
+					It("should derive the BMC address and credentials, and override them by the params", func() {
+						Eventually(func(g Gomega) {
+							g.Expect(storedCommand).To(ConsistOf([]string{
+								"fence_ipmilan",
+								"--lanplus",
+								"--password=bmc-password",
+								"--username=bmc-admin",
+								"--action=reboot",
+								"--ip=192.168.111.1",
+								"--pass2=abc2",
+								"--pass=abc",
+								"--ipport=6234"}))
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+						Expect(storedOverStdin).To(BeTrue())
+					})
+				})
+				When("the node doesn't refer to its Machine", func() {
+					It("A missing BareMetalHost would prevent execution of fence agent command", func() {
+						Consistently(func(g Gomega) {
+							g.Expect(storedCommand).To(BeEmpty())
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+						verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+					})
+				})
+			})
+			When("Params are derived from the provider ID of the node", func() {
+				BeforeEach(func() {
+					node.Spec.ProviderID = "aws:///us-east-1a/i-0123456789abcdef0"
+					underTestFAR = getFenceAgentsRemediation(workerNode, "fence_aws", nil, nil, v1alpha1.ResourceDeletionRemediationStrategy)
+					underTestFAR.Spec.ParametersSource = v1alpha1.ProviderIDParametersSource
+				})
+				It("should derive the instance ID and the region", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ConsistOf([]string{
+							"fence_aws",
+							"--action=reboot",
+							"--plug=i-0123456789abcdef0",
+							"--region=us-east-1",
+							"--pass2=abc2",
+							"--pass=abc"}))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				})
+			})
+			When("Params refer to keys of a Secret and a ConfigMap", func() {
+				referenceShareParam := map[v1alpha1.ParameterName]string{
+					"--username": "admin",
+					"--lanplus":  "",
+				}
+				referenceParams := func(secretKey string) map[v1alpha1.ParameterName]v1alpha1.ParameterValueSource {
+					return map[v1alpha1.ParameterName]v1alpha1.ParameterValueSource{
+						"--ip": {ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "bmc-config"}, Key: "address"}},
+						"--password": {SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "bmc-creds"}, Key: secretKey}},
+					}
+				}
+				BeforeEach(func() {
+					bmcSecret := generateSecret("bmc-creds", map[string][]byte{
+						"pw":             []byte("bmc-password"),
+						"other-tool-key": []byte("other-tool-value"),
+					})
+					Expect(k8sClient.Create(context.Background(), bmcSecret)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), bmcSecret)
+
+					bmcConfigMap := &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "bmc-config", Namespace: defaultNamespace},
+						Data:       map[string]string{"address": "192.168.111.1"},
+					}
+					Expect(k8sClient.Create(context.Background(), bmcConfigMap)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), bmcConfigMap)
+
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, referenceShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				})
+				When("the referenced keys exist", func() {
+					BeforeEach(func() {
+						underTestFAR.Spec.ParametersFrom = referenceParams("pw")
+					})
+					It("should pass only the referenced keys as params", func() {
+						Eventually(func(g Gomega) {
+							g.Expect(storedCommand).To(ConsistOf([]string{
+								"fence_ipmilan",
+								"--lanplus",
+								"--password=bmc-password",
+								"--username=admin",
+								"--action=reboot",
+								"--ip=192.168.111.1",
+								"--pass2=abc2",
+								"--pass=abc",
+								"--ipport=6233"}))
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+						Expect(storedOverStdin).To(BeTrue())
+					})
+				})
+				When("a referenced key is missing", func() {
+					BeforeEach(func() {
+						underTestFAR.Spec.ParametersFrom = referenceParams("password")
+					})
+					It("A missing key would prevent execution of fence agent command", func() {
+						Consistently(func(g Gomega) {
+							g.Expect(storedCommand).To(BeEmpty())
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+						verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+					})
+				})
+			})
+			When("A param is defined in a Secret", func() {
+				BeforeEach(func() {
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				})
+				It("should pass the params over stdin by default", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--pass=abc"))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					Expect(storedOverStdin).To(BeTrue())
+				})
+			})
+			When("No param is defined in a Secret", func() {
+				BeforeEach(func() {
+					nodeSecret = generateSecret(nodeSecretName, map[string][]byte{})
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				})
+				It("should pass the params on the command line by default", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--ipport=6233"))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					Expect(storedOverStdin).To(BeFalse())
+				})
+			})
+			When("The params transport is CommandLine", func() {
+				BeforeEach(func() {
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					underTestFAR.Spec.ParametersTransport = v1alpha1.CommandLineParametersTransport
+				})
+				It("should pass the Secret params on the command line", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--pass=abc"))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					Expect(storedOverStdin).To(BeFalse())
+				})
+			})
+		})
+		When("creating valid FAR CR", func() {
+			var successfulAttempts, remediations float64
+
+			testSuccessfulRemediation := func() {
+				Eventually(func(g Gomega) {
+					g.Expect(storedCommand).To(ConsistOf([]string{
+						"fence_ipmilan",
+						"--pass=abc",
+						"--pass2=abc2",
+						"--lanplus",
+						"--password=password",
+						"--username=admin",
+						"--action=reboot",
+						"--ip=192.168.111.1",
+						"--ipport=6233"}))
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+
+				underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+				By("Not having any test pod")
+				verifyPodDeleted(testPodName)
+
+				By("Verifying correct conditions for successful remediation")
+				verifyRemediationConditions(
+					underTestFAR,
+					conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+					conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+					conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				verifyEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+				verifyEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventMessageNodeRemediationCompleted)
+
+				By("Recording the fencing metrics")
+				Expect(getMetricValue("far_fence_agent_attempts_total", map[string]string{"agent": fenceAgentIPMI, "result": metrics.AttemptResultSuccess})).To(BeNumerically(">", successfulAttempts))
+				// the remediation duration is observed once, after the remediation success was persisted
+				Eventually(func() float64 {
+					return getMetricValue("far_remediation_duration_seconds", nil)
+				}, timeoutPostRemediation, pollInterval).Should(Equal(remediations + 1))
+				Consistently(func() float64 {
+					return getMetricValue("far_remediation_duration_seconds", nil)
+				}, timeoutPreRemediation, pollInterval).Should(Equal(remediations + 1))
+				Expect(getMetricValue("far_remediation_pods_deleted", nil)).To(BeNumerically(">", remediations))
+			}
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				successfulAttempts = getMetricValue("far_fence_agent_attempts_total", map[string]string{"agent": fenceAgentIPMI, "result": metrics.AttemptResultSuccess})
+				remediations = getMetricValue("far_remediation_duration_seconds", nil)
+			})
+			When("node name is stored in remediation name", func() {
+				It("should have finalizer and taint, while the tested pod will be deleted", testSuccessfulRemediation)
+			})
+			//remediation is created from escalation remediation supporting same kind template
+			When("node name is stored in remediation's annotation", func() {
+				BeforeEach(func() {
+					underTestFAR.Name = fmt.Sprintf("%s-%s", workerNode, "pseudo-random-test-sufix")
+					underTestFAR.Annotations = map[string]string{"remediation.medik8s.io/node-name": workerNode}
+				})
+				It("should have finalizer and taint, while the tested pod will be deleted", testSuccessfulRemediation)
+			})
+			When("the node has volume attachments", func() {
+				const volumeAttachmentName = "far-test-volume-attachment"
+				const persistentVolumeName = "far-test-pv"
+
+				BeforeEach(func() {
+					pvName := persistentVolumeName
+					volumeAttachment := &storagev1.VolumeAttachment{
+						ObjectMeta: metav1.ObjectMeta{Name: volumeAttachmentName},
+						Spec: storagev1.VolumeAttachmentSpec{
+							Attacher: "test.csi.driver",
+							NodeName: workerNode,
+							Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+						},
+					}
+					Expect(k8sClient.Create(context.Background(), volumeAttachment)).To(Succeed())
+					DeferCleanup(func() {
+						Expect(client.IgnoreNotFound(k8sClient.Delete(context.Background(), volumeAttachment))).To(Succeed())
+					})
+				})
+
+				It("should delete the volume attachments of the node", func() {
+					testSuccessfulRemediation()
+
+					By("Not having the volume attachment")
+					Eventually(func(g Gomega) {
+						err := k8sClient.Get(context.Background(), client.ObjectKey{Name: volumeAttachmentName}, &storagev1.VolumeAttachment{})
+						g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.DeletedVolumeAttachments).To(Equal(1))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonDeleteVolumeAttachment,
+						fmt.Sprintf(utils.EventMessageDeleteVolumeAttachment, volumeAttachmentName, persistentVolumeName))
+				})
+			})
+			When("the pod selection skips the namespace of the tested pod", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.PodSelection = &v1alpha1.PodSelection{ExcludedNamespaces: []string{defaultNamespace}}
+				})
+
+				It("should complete the remediation, while the tested pod will remain", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+
+					By("Still having one test pod")
+					verifyPodExists(testPodName)
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.DeletedPods).To(BeZero())
+					Expect(underTestFAR.Status.SkippedPods).To(Equal(1))
+				})
+			})
+		})
+
+		When("creating invalid FAR CR Name", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(dummyNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+			})
+
+			It("should not have a finalizer nor taint, while the tested pod will remain", func() {
+				By("Not finding a matching node to FAR CR's name")
+				Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: underTestFAR.Name}, node)).To(Not(Succeed()))
+
+				By("Not having finalizer")
+				Consistently(func(g Gomega) bool {
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: underTestFAR.Name, Namespace: defaultNamespace}, underTestFAR)).To(Succeed())
+					return controllerutil.ContainsFinalizer(underTestFAR, v1alpha1.FARFinalizer)
+				}, timeoutPreRemediation, pollInterval).Should(BeFalse(), "finalizer shouldn't be added")
+				verifyEvent(corev1.EventTypeWarning, utils.EventReasonCrNodeNotFound, utils.EventMessageCrNodeNotFound)
+				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonRemediationStarted, utils.EventMessageRemediationStarted)
+
+				// If finalizer is missing, then a taint shouldn't exist
+				By("Not having remediation taint")
+				Expect(utils.TaintExists(node.Spec.Taints, &farRemediationTaint)).To(BeFalse())
+				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonAddRemediationTaint, utils.EventMessageAddRemediationTaint)
+
+				By("Still having one test pod")
+				verifyPodExists(testPodName)
+
+				By("Verifying correct conditions for unsuccessful remediation")
+				verifyRemediationConditions(
+					underTestFAR,
+					conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+					conditionStatusPointer(metav1.ConditionFalse), // FenceAgentActionSucceededTypeStatus
+					conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
+				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventReasonNodeRemediationCompleted)
+			})
+		})
+
+		Context("Fencing modes which power off the node", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, noActionShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+			})
+
+			When("the fencing mode is OffThenOn", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.FencingMode = v1alpha1.OffThenOnFencingMode
+				})
+
+				It("should power off the node, delete its workloads, and then power on the node", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Not having any test pod")
+					verifyPodDeleted(testPodName)
+
+					By("Powering on the node")
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--action=on"))
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+
+					By("Verifying correct conditions for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					verifyNodePoweredOnCondition(underTestFAR, conditionStatusPointer(metav1.ConditionTrue))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonPowerOnNode, utils.EventMessagePowerOnNode)
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonNodePoweredOn, utils.EventMessageNodePoweredOn)
+				})
+			})
+
+			When("the fencing mode is OffOnly", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.FencingMode = v1alpha1.OffOnlyFencingMode
+				})
+
+				It("should keep the node powered off, and power it on when the CR is deleted", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--action=off"))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Not having any test pod")
+					verifyPodDeleted(testPodName)
+
+					By("Verifying correct conditions for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					verifyNodePoweredOnCondition(underTestFAR, nil)
+					Expect(storedCommand).To(ContainElement("--action=off"))
+
+					By("Deleting FAR CR")
+					Expect(k8sClient.Delete(context.Background(), underTestFAR)).To(Succeed())
+
+					By("Powering on the node before removing the CR")
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ContainElement("--action=on"))
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					Eventually(func() bool {
+						return apierrors.IsNotFound(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), &v1alpha1.FenceAgentsRemediation{}))
+					}, timeoutPostRemediation, pollInterval).Should(BeTrue())
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonNodePoweredOn, utils.EventMessageNodePoweredOn)
+				})
+			})
+
+			When("the action parameter doesn't match the fencing mode", func() {
+				BeforeEach(func() {
+					underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					underTestFAR.Spec.FencingMode = v1alpha1.OffOnlyFencingMode
+				})
+
+				It("A validation error would prevent execution of fence agent command", func() {
+					Consistently(func(g Gomega) {
+						g.Expect(storedCommand).To(BeEmpty())
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+				})
+			})
+		})
+
+		Context("Fence agent power status verification", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR.Spec.Verification = &v1alpha1.FenceAgentVerification{
+					Timeout:  metav1.Duration{Duration: 1 * time.Second},
+					Interval: metav1.Duration{Duration: 100 * time.Millisecond},
+				}
+			})
+
+			When("the fence agent reports the node is powered on", func() {
+				It("should verify the power status and complete the remediation", func() {
+					Eventually(func(g Gomega) {
+						g.Expect(storedStatusCommand).To(ConsistOf([]string{
+							"fence_ipmilan",
+							"--pass=abc",
+							"--pass2=abc2",
+							"--lanplus",
+							"--password=password",
+							"--username=admin",
+							"--action=status",
+							"--ip=192.168.111.1",
+							"--ipport=6233"}))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Not having any test pod")
+					verifyPodDeleted(testPodName)
+
+					By("Verifying correct conditions for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					verifyVerificationCondition(underTestFAR, conditionStatusPointer(metav1.ConditionTrue))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentVerified, utils.EventMessageFenceAgentVerified)
+				})
+			})
+
+			When("the fence agent doesn't report the node is powered on", func() {
+				BeforeEach(func() {
+					mockPowerStatus = cli.PowerStatusOff
+					DeferCleanup(func() { mockPowerStatus = cli.PowerStatusOn })
+				})
+
+				It("should fail the verification and keep the workloads", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Still having one test pod")
+					verifyPodExists(testPodName)
+
+					By("Verifying correct conditions for un-successful verification")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
+					verifyVerificationCondition(underTestFAR, conditionStatusPointer(metav1.ConditionFalse))
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonFenceAgentNotVerified, utils.EventMessageFenceAgentNotVerified)
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventMessageNodeRemediationCompleted)
+				})
+			})
+		})
+
+		Context("Fallback fencing levels", func() {
+			const fenceAgentRedfish = "fence_redfish"
+
+			BeforeEach(func() {
+				plogs.Clear()
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR.Spec.RetryCount = 2
+				underTestFAR.Spec.RetryInterval = metav1.Duration{Duration: 1 * time.Millisecond}
+				underTestFAR.Spec.FallbackLevels = []v1alpha1.FencingLevel{{
+					Agent:         fenceAgentRedfish,
+					RetryCount:    1,
+					RetryInterval: metav1.Duration{Duration: 1 * time.Millisecond},
+					Timeout:       metav1.Duration{Duration: 60 * time.Second},
+					SharedParameters: map[v1alpha1.ParameterName]string{
+						"--username": "redfish-admin",
+						"--ip":       "192.168.111.2",
+					},
+				}}
+			})
+
+			When("the first fencing level fails", func() {
+				BeforeEach(func() {
+					mockFailingAgent = fenceAgentIPMI
+					DeferCleanup(func() { mockFailingAgent = "" })
+				})
+
+				It("should fence the node with the fallback fencing level and record it in the status", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Falling back to the next fencing level")
+					Eventually(func() bool {
+						return plogs.Contains(cli.FenceAgentFallbackMessage)
+					}, timeoutPreRemediation, pollInterval).Should(BeTrue())
+					Eventually(func(g Gomega) {
+						g.Expect(storedCommand).To(ConsistOf([]string{
+							fenceAgentRedfish,
+							"--username=redfish-admin",
+							"--ip=192.168.111.2",
+							"--action=reboot"}))
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					Expect(plogs.CountOccurences(cli.FenceAgentFailedCommandMessage)).To(Equal(2))
+
+					By("Not having any test pod")
+					verifyPodDeleted(testPodName)
+
+					By("Verifying correct conditions and fencing level for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.FencedBy).To(Equal(&v1alpha1.FencingLevelStatus{Level: 2, Agent: fenceAgentRedfish}))
+				})
+			})
+
+			When("the first fencing level succeeds", func() {
+				It("should not try the fallback fencing level", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Verifying correct conditions and fencing level for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.FencedBy).To(Equal(&v1alpha1.FencingLevelStatus{Level: 1, Agent: fenceAgentIPMI}))
+					Expect(storedCommand[0]).To(Equal(fenceAgentIPMI))
+					Expect(plogs.Contains(cli.FenceAgentFallbackMessage)).To(BeFalse())
+				})
+			})
+		})
+
+		Context("Multi-device fencing", func() {
+			const fenceAgentAPC = "fence_apc_snmp"
+
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentAPC, map[v1alpha1.ParameterName]string{"--username": "admin"}, nil, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR.Spec.Devices = []v1alpha1.FencingDevice{
+					{
+						SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.10"},
+						NodeParameters:   map[v1alpha1.ParameterName]map[v1alpha1.NodeName]string{"--plug": {workerNode: "3"}},
+					},
+					{
+						SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.11"},
+						NodeParameters:   map[v1alpha1.ParameterName]map[v1alpha1.NodeName]string{"--plug": {workerNode: "5"}},
+					},
+				}
+			})
+
+			When("the node is rebooted by two fencing devices", func() {
+				It("should power off both devices before powering on any of them", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Not having any test pod")
+					verifyPodDeleted(testPodName)
+
+					By("Powering off all the devices, and only then powering them on")
+					device1 := []string{fenceAgentAPC, "--pass=abc", "--pass2=abc2", "--username=admin", "--ip=192.168.111.10", "--plug=3"}
+					device2 := []string{fenceAgentAPC, "--pass=abc", "--pass2=abc2", "--username=admin", "--ip=192.168.111.11", "--plug=5"}
+					Expect(storedCommands).To(HaveLen(4))
+					Expect(storedCommands[0]).To(ConsistOf(append(device1, "--action=off")))
+					Expect(storedCommands[1]).To(ConsistOf(append(device2, "--action=off")))
+					Expect(storedCommands[2]).To(ConsistOf(append(device1, "--action=on")))
+					Expect(storedCommands[3]).To(ConsistOf(append(device2, "--action=on")))
+
+					By("Verifying correct conditions for successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				})
+			})
+
+			When("a fencing device has an unsupported action", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.Devices[1].SharedParameters["--action"] = "off"
+				})
+
+				It("should report the invalid fencing level without executing the fence agent", func() {
+					Consistently(func(g Gomega) {
+						g.Expect(storedCommands).To(BeEmpty())
+					}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonInvalidFencingLevel, fmt.Sprintf(utils.EventMessageInvalidFencingLevel, 1,
+						"FAR doesn't support any other action than reboot in this fencing mode"))
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+				})
+			})
+
+			When("one of the fencing devices fails", func() {
+				BeforeEach(func() {
+					mockError = errors.New("mock error")
+					DeferCleanup(func() { mockError = nil })
+
+					underTestFAR.Spec.RetryCount = 1
+				})
+
+				It("should fail the fence agent without powering on any device", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Still having one test pod")
+					verifyPodExists(testPodName)
+
+					By("Verifying correct conditions for un-successful remediation")
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionFalse), // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
+					Expect(storedCommands).To(HaveLen(1))
+					Expect(storedCommands[0]).To(ContainElement("--action=off"))
+				})
+			})
+		})
+
+		Context("Cluster-wide fencing limits", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+			})
+
+			When("the number of unhealthy nodes is above the remediation storm threshold", func() {
+				BeforeEach(func() {
+					stormThreshold := intstr.FromInt32(0)
+					farReconciler.StormThreshold = &stormThreshold
+					DeferCleanup(func() { farReconciler.StormThreshold = nil })
+				})
+
+				It("should not fence the node until the remediation storm is over", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Waiting for the remediation storm to be over")
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.RemediationStormDetected)
+					nodes := &corev1.NodeList{}
+					Expect(k8sClient.List(context.Background(), nodes)).To(Succeed())
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonRemediationStorm, fmt.Sprintf(utils.EventMessageRemediationStorm, 1, len(nodes.Items), 0))
+					Expect(storedCommand).To(BeEmpty())
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentExecuted, utils.EventMessageFenceAgentExecuted)
+
+					By("Fencing the node once the remediation storm is over")
+					stormThreshold := intstr.FromString("100%")
+					farReconciler.StormThreshold = &stormThreshold
+					triggerReconcile(underTestFAR)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionFalse, utils.FencingAdmitted)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				})
+			})
+
+			When("the maximal number of nodes are already being fenced", func() {
+				const fencedNodeName = "worker-1"
+				var fencedFAR *v1alpha1.FenceAgentsRemediation
+
+				BeforeEach(func() {
+					maxConcurrentFencing := intstr.FromInt32(1)
+					farReconciler.MaxConcurrentFencing = &maxConcurrentFencing
+					DeferCleanup(func() { farReconciler.MaxConcurrentFencing = nil })
+
+					// another node is being fenced by a slow fence agent
+					forcedDelay = 10 * time.Second
+					DeferCleanup(func() { forcedDelay = 0 })
+					fencedNode := utils.GetNode("", fencedNodeName)
+					Expect(k8sClient.Create(context.Background(), fencedNode)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), fencedNode)
+					fencedFAR = getFenceAgentsRemediation(fencedNodeName, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					Expect(k8sClient.Create(context.Background(), fencedFAR)).To(Succeed())
+					DeferCleanup(cleanupFar, context.Background(), fencedFAR)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(fencedFAR), fencedFAR)).To(Succeed())
+						g.Expect(fencedFAR.Status.Execution).ToNot(BeNil())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+				})
+
+				It("should queue the node until the other node isn't fenced anymore", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Queueing the node")
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.FencingConcurrencyLimitReached)
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonFencingQueued, fmt.Sprintf(utils.EventMessageFencingQueued, 1, 1))
+					Expect(storedCommands).To(HaveLen(1))
+					Expect(storedCommands[0]).To(ContainElement("--ipport=6234"))
+
+					By("Fencing the node once the remediation of the other node is deleted")
+					forcedDelay = 0
+					Expect(cleanupFar(context.Background(), fencedFAR)).To(Succeed())
+					triggerReconcile(underTestFAR)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionFalse, utils.FencingAdmitted)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				})
+			})
+
+			When("two queued nodes can be admitted at the same time", func() {
+				const fencedNodeName, queuedNodeName = "worker-1", "worker-2"
+				var fencedFAR, queuedFAR *v1alpha1.FenceAgentsRemediation
+
+				BeforeEach(func() {
+					maxConcurrentFencing := intstr.FromInt32(1)
+					farReconciler.MaxConcurrentFencing = &maxConcurrentFencing
+					DeferCleanup(func() { farReconciler.MaxConcurrentFencing = nil })
+
+					// another node is being fenced by a slow fence agent, and the admitted node is fenced slowly as well
+					forcedDelay = 10 * time.Second
+					DeferCleanup(func() { forcedDelay = 0 })
+					for _, nodeName := range []string{fencedNodeName, queuedNodeName} {
+						otherNode := utils.GetNode("", nodeName)
+						Expect(k8sClient.Create(context.Background(), otherNode)).To(Succeed())
+						DeferCleanup(k8sClient.Delete, context.Background(), otherNode)
+					}
+					fencedFAR = getFenceAgentsRemediation(fencedNodeName, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					Expect(k8sClient.Create(context.Background(), fencedFAR)).To(Succeed())
+					DeferCleanup(cleanupFar, context.Background(), fencedFAR)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(fencedFAR), fencedFAR)).To(Succeed())
+						g.Expect(fencedFAR.Status.Execution).ToNot(BeNil())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					queuedFAR = getFenceAgentsRemediation(queuedNodeName, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+					Expect(k8sClient.Create(context.Background(), queuedFAR)).To(Succeed())
+					DeferCleanup(cleanupFar, context.Background(), queuedFAR)
+				})
+
+				It("should admit only one of the queued nodes", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.FencingConcurrencyLimitReached)
+					verifyWaitingCondition(queuedFAR, metav1.ConditionTrue, utils.FencingConcurrencyLimitReached)
+
+					By("Admitting the queued nodes at the same time once the remediation of the other node is deleted")
+					Expect(cleanupFar(context.Background(), fencedFAR)).To(Succeed())
+					triggerReconcile(underTestFAR)
+					triggerReconcile(queuedFAR)
+					admittedNodes := func(g Gomega) int {
+						admitted := 0
+						for _, far := range []*v1alpha1.FenceAgentsRemediation{underTestFAR, queuedFAR} {
+							farCR := &v1alpha1.FenceAgentsRemediation{}
+							g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), farCR)).To(Succeed())
+							if !meta.IsStatusConditionTrue(farCR.Status.Conditions, utils.WaitingType) {
+								admitted++
+							}
+						}
+						return admitted
+					}
+					Eventually(admittedNodes, timeoutPostRemediation, pollInterval).Should(Equal(1))
+					Consistently(admittedNodes, 5*time.Second, pollInterval).Should(Equal(1))
+				})
+			})
 		})
-		When("creating valid FAR CR", func() {
 
-			testSuccessfulRemediation := func() {
+		Context("Control-plane quorum protection", func() {
+			otherControlPlaneNodes := []string{"master-1", "master-2"}
+
+			BeforeEach(func() {
+				// the node under test is a control-plane node
+				node = utils.GetNode("", workerNode)
+				node.Labels = map[string]string{medik8sLabels.ControlPlaneRole: ""}
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+
+				// the other control-plane nodes aren't ready, since envtest has no kubelets
+				for _, nodeName := range otherControlPlaneNodes {
+					controlPlaneNode := utils.GetNode("", nodeName)
+					controlPlaneNode.Labels = map[string]string{medik8sLabels.MasterRole: ""}
+					Expect(k8sClient.Create(context.Background(), controlPlaneNode)).To(Succeed())
+					DeferCleanup(k8sClient.Delete, context.Background(), controlPlaneNode)
+				}
+			})
+
+			When("fencing the node would break the etcd quorum", func() {
+				It("should not fence the node until the other control-plane nodes are ready", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+					By("Waiting for the other control-plane nodes")
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.ControlPlaneQuorumProtected)
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonQuorumProtected, fmt.Sprintf(utils.EventMessageQuorumProtected, 0, 2, 2))
+					Expect(storedCommand).To(BeEmpty())
+
+					By("Fencing the node once the other control-plane nodes are ready")
+					for _, nodeName := range otherControlPlaneNodes {
+						Eventually(func(g Gomega) {
+							controlPlaneNode := &corev1.Node{}
+							g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: nodeName}, controlPlaneNode)).To(Succeed())
+							controlPlaneNode.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+							g.Expect(k8sClient.Status().Update(context.Background(), controlPlaneNode)).To(Succeed())
+						}, timeoutPreRemediation, pollInterval).Should(Succeed())
+					}
+					triggerReconcile(underTestFAR)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionFalse, utils.FencingAdmitted)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				})
+			})
+
+			When("the etcd quorum loss is allowed by annotation", func() {
+				BeforeEach(func() {
+					underTestFAR.Annotations = map[string]string{v1alpha1.AllowQuorumLossAnnotation: "true"}
+				})
+
+				It("should fence the node", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonQuorumLossAllowed, utils.EventMessageQuorumLossAllowed)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					Expect(meta.FindStatusCondition(underTestFAR.Status.Conditions, utils.WaitingType)).To(BeNil())
+				})
+			})
+		})
+
+		Context("Node reboot verification", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR.Spec.RebootVerification = &v1alpha1.NodeRebootVerification{Timeout: metav1.Duration{Duration: 5 * time.Minute}}
+			})
+
+			When("the node's boot ID changes after it was fenced", func() {
+				BeforeEach(func() {
+					// the node waits to be fenced until its boot ID is set
+					stormThreshold := intstr.FromInt32(0)
+					farReconciler.StormThreshold = &stormThreshold
+					DeferCleanup(func() { farReconciler.StormThreshold = nil })
+				})
+
+				It("should verify the node reboot", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.RemediationStormDetected)
+					updateNodeStatus(workerNode, func(status *corev1.NodeStatus) { status.NodeInfo.BootID = "boot-1" })
+					farReconciler.StormThreshold = nil
+					triggerReconcile(underTestFAR)
+
+					By("Recording the boot ID before fencing")
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(underTestFAR.Status.PreFenceBootID).To(Equal("boot-1"))
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, utils.FenceAgentActionSucceededType)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyNodeRebootVerifiedCondition(underTestFAR, conditionStatusPointer(metav1.ConditionUnknown))
+					Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, commonConditions.SucceededType)).To(BeFalse())
+
+					By("Removing the workloads only once while waiting for the reboot")
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(underTestFAR.Status.WorkloadsRemovedTime).NotTo(BeNil())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					deletedPods := underTestFAR.Status.DeletedPods
+					// e.g. a static pod which is recreated once the node has rebooted
+					recreatedPod := createRunningPod("far-test-2", "far-test-2-pod", workerNode)
+					DeferCleanup(cleanupTestedResources, recreatedPod)
+					triggerReconcile(underTestFAR)
+					Consistently(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(recreatedPod), &corev1.Pod{})).To(Succeed())
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(underTestFAR.Status.DeletedPods).To(Equal(deletedPods))
+					}, 5*time.Second, pollInterval).Should(Succeed())
+
+					By("Succeeding once the boot ID has changed")
+					updateNodeStatus(workerNode, func(status *corev1.NodeStatus) { status.NodeInfo.BootID = "boot-2" })
+					triggerReconcile(underTestFAR)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, commonConditions.SucceededType)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyNodeRebootVerifiedCondition(underTestFAR, conditionStatusPointer(metav1.ConditionTrue))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonNodeRebootVerified, utils.EventMessageNodeRebootVerified)
+				})
+			})
+
+			When("the node becomes ready after it was fenced", func() {
+				It("should verify the node reboot", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, utils.FenceAgentActionSucceededType)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyNodeRebootVerifiedCondition(underTestFAR, conditionStatusPointer(metav1.ConditionUnknown))
+
+					// the Ready condition's transition time must be after the fence time, which has a resolution of seconds
+					time.Sleep(time.Second)
+					updateNodeStatus(workerNode, func(status *corev1.NodeStatus) {
+						status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+					})
+					triggerReconcile(underTestFAR)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, commonConditions.SucceededType)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyNodeRebootVerifiedCondition(underTestFAR, conditionStatusPointer(metav1.ConditionTrue))
+				})
+			})
+
+			When("the node doesn't reboot within the reboot verification timeout", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.RebootVerification.Timeout = metav1.Duration{Duration: time.Second}
+				})
+
+				It("should fail the remediation", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionFalse(underTestFAR.Status.Conditions, utils.NodeRebootVerifiedType)).To(BeTrue())
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
+					verifyEvent(corev1.EventTypeWarning, utils.EventReasonNodeRebootNotVerified, utils.EventMessageNodeRebootNotVerified)
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventMessageNodeRemediationCompleted)
+				})
+			})
+		})
+
+		Context("Manager on the fenced node", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+
+				// the manager pod runs on the node under test
+				cleanupTestedResources(buildPod("far-manager-test", farPodName, ""))
+				managerPod := createRunningPod("far-manager-test", farPodName, workerNode)
+				DeferCleanup(cleanupTestedResources, managerPod)
+			})
+
+			It("should hand off the node fencing to a manager on another node", func() {
+				underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+
+				By("Deleting the manager pod instead of fencing the node")
+				verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.FencingHandedOff)
+				verifyEvent(corev1.EventTypeWarning, utils.EventReasonFencingHandedOff, fmt.Sprintf(utils.EventMessageFencingHandedOff, farPodName))
 				Eventually(func(g Gomega) {
-					g.Expect(storedCommand).To(ConsistOf([]string{
-						"fence_ipmilan",
-						"--pass=abc",
-						"--pass2=abc2",
-						"--lanplus",
-						"--password=password",
-						"--username=admin",
-						"--action=reboot",
-						"--ip=192.168.111.1",
-						"--ipport=6233"}))
+					managerPod := &corev1.Pod{}
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: defaultNamespace, Name: farPodName}, managerPod)).To(Succeed())
+					g.Expect(managerPod.DeletionTimestamp).ToNot(BeNil())
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				Expect(storedCommand).To(BeEmpty())
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					g.Expect(underTestFAR.Status.FencingHandOffTime).ToNot(BeNil())
 				}, timeoutPreRemediation, pollInterval).Should(Succeed())
 
-				underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+				By("Not deleting the manager pod again while waiting")
+				cleanupTestedResources(buildPod("far-manager-test", farPodName, ""))
+				createRunningPod("far-manager-test", farPodName, workerNode)
+				triggerReconcile(underTestFAR)
+				Consistently(func(g Gomega) {
+					managerPod := &corev1.Pod{}
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: defaultNamespace, Name: farPodName}, managerPod)).To(Succeed())
+					g.Expect(managerPod.DeletionTimestamp).To(BeNil())
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.FencingHandedOff)
 
-				By("Not having any test pod")
-				verifyPodDeleted(testPodName)
+				By("Deleting the manager pod again once it still runs on the node after the hand off timeout")
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					handOffTime := metav1.NewTime(time.Now().Add(-fencingHandOffTimeout))
+					underTestFAR.Status.FencingHandOffTime = &handOffTime
+					g.Expect(k8sClient.Status().Update(context.Background(), underTestFAR)).To(Succeed())
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				triggerReconcile(underTestFAR)
+				// the message is spelled out, so a wrong argument of the message format fails the test
+				verifyEvent(corev1.EventTypeWarning, utils.EventReasonFencingHandOffTimedOut,
+					"The manager pod far-pod still runs on node worker-0 5m0s after the node fencing was handed off, thus it is deleted again")
+				Eventually(func(g Gomega) {
+					managerPod := &corev1.Pod{}
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Namespace: defaultNamespace, Name: farPodName}, managerPod)).To(Succeed())
+					g.Expect(managerPod.DeletionTimestamp).ToNot(BeNil())
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					g.Expect(time.Since(underTestFAR.Status.FencingHandOffTime.Time)).To(BeNumerically("<", fencingHandOffTimeout))
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				Expect(storedCommand).To(BeEmpty())
 
-				By("Verifying correct conditions for successful remediation")
+				By("Fencing the node once the manager runs on another node")
+				cleanupTestedResources(buildPod("far-manager-test", farPodName, ""))
+				createRunningPod("far-manager-test", farPodName, "")
+				triggerReconcile(underTestFAR)
+				verifyWaitingCondition(underTestFAR, metav1.ConditionFalse, utils.FencingAdmitted)
 				verifyRemediationConditions(
 					underTestFAR,
 					conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
 					conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
 					conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
-				verifyEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
-				verifyEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventMessageNodeRemediationCompleted)
-			}
+			})
+		})
+
+		Context("Another manager replica on the fenced node", func() {
+			const replicaPodName = "far-pod-replica"
+
 			BeforeEach(func() {
 				node = utils.GetNode("", workerNode)
 				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+
+				// this manager runs on another node, while a standby replica runs on the node under test
+				cleanupTestedResources(buildPod("far-manager-test", farPodName, ""))
+				managerPod := createRunningPod("far-manager-test", farPodName, "")
+				DeferCleanup(cleanupTestedResources, managerPod)
+				replicaPod := buildPod("far-manager-test", replicaPodName, workerNode)
+				replicaPod.Labels = faPodLabels
+				Expect(k8sClient.Create(context.Background(), replicaPod)).To(Succeed())
+				DeferCleanup(cleanupTestedResources, replicaPod)
 			})
-			When("node name is stored in remediation name", func() {
-				It("should have finalizer and taint, while the tested pod will be deleted", testSuccessfulRemediation)
-			})
-			//remediation is created from escalation remediation supporting same kind template
-			When("node name is stored in remediation's annotation", func() {
-				BeforeEach(func() {
-					underTestFAR.Name = fmt.Sprintf("%s-%s", workerNode, "pseudo-random-test-sufix")
-					underTestFAR.Annotations = map[string]string{"remediation.medik8s.io/node-name": workerNode}
-				})
-				It("should have finalizer and taint, while the tested pod will be deleted", testSuccessfulRemediation)
+
+			It("should fence the node without deleting the replica pod", func() {
+				underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+				verifyRemediationConditions(
+					underTestFAR,
+					conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+					conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+					conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+				verifyNoEvent(corev1.EventTypeWarning, utils.EventReasonFencingHandedOff, fmt.Sprintf(utils.EventMessageFencingHandedOff, replicaPodName))
+				Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+				Expect(underTestFAR.Status.FencingHandOffTime).To(BeNil())
 			})
 		})
 
-		When("creating invalid FAR CR Name", func() {
+		Context("Manager restart", func() {
+			waitForExecution := func() *v1alpha1.FenceAgentExecution {
+				far := &v1alpha1.FenceAgentsRemediation{}
+				Eventually(func(g Gomega) {
+					g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), far)).To(Succeed())
+					g.Expect(far.Status.Execution).ToNot(BeNil())
+				}, timeoutPreRemediation, pollInterval).Should(Succeed())
+				return far.Status.Execution
+			}
+
 			BeforeEach(func() {
+				plogs.Clear()
 				node = utils.GetNode("", workerNode)
-				underTestFAR = getFenceAgentsRemediation(dummyNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.ResourceDeletionRemediationStrategy)
+				underTestFAR.Spec.Timeout = metav1.Duration{Duration: 3 * time.Second}
+				// the fence agent is still running when the manager restarts
+				forcedDelay = 10 * time.Second
+				DeferCleanup(func() { forcedDelay = 0 })
 			})
 
-			It("should not have a finalizer nor taint, while the tested pod will remain", func() {
-				By("Not finding a matching node to FAR CR's name")
-				Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: underTestFAR.Name}, node)).To(Not(Succeed()))
+			When("the manager restarts while the node is rebooted", func() {
+				It("should wait for the interrupted attempt to time out, and then resume the execution", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					execution := waitForExecution()
+					Expect(execution.Owner).To(Equal(executor.Identity()))
+					Expect(execution.Level).To(Equal(1))
+					Expect(execution.Attempt).To(Equal(1))
 
-				By("Not having finalizer")
-				Consistently(func(g Gomega) bool {
-					g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: underTestFAR.Name, Namespace: defaultNamespace}, underTestFAR)).To(Succeed())
-					return controllerutil.ContainsFinalizer(underTestFAR, v1alpha1.FARFinalizer)
-				}, timeoutPreRemediation, pollInterval).Should(BeFalse(), "finalizer shouldn't be added")
-				verifyEvent(corev1.EventTypeWarning, utils.EventReasonCrNodeNotFound, utils.EventMessageCrNodeNotFound)
-				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonRemediationStarted, utils.EventMessageRemediationStarted)
+					By("Restarting the manager")
+					forcedDelay = 0
+					simulateManagerRestart(underTestFAR, "previous-manager-reboot")
 
-				// If finalizer is missing, then a taint shouldn't exist
-				By("Not having remediation taint")
-				Expect(utils.TaintExists(node.Spec.Taints, &farRemediationTaint)).To(BeFalse())
-				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonAddRemediationTaint, utils.EventMessageAddRemediationTaint)
+					By("Waiting for the interrupted attempt to time out")
+					Eventually(func() bool {
+						return plogs.Contains("Waiting for the fence agent attempt of a previous manager to time out")
+					}, timeoutPreRemediation, pollInterval).Should(BeTrue())
+					Expect(storedCommands).To(HaveLen(1))
 
-				By("Still having one test pod")
-				verifyPodExists(testPodName)
+					By("Resuming the execution and completing the remediation")
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, commonConditions.SucceededType)).To(BeTrue())
+					}, "10s", pollInterval).Should(Succeed())
+					Expect(underTestFAR.Status.Execution).To(BeNil())
+					Expect(storedCommands).To(HaveLen(2))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentResumed, fmt.Sprintf(utils.EventMessageFenceAgentResumed, 1, 1))
+					verifyPodDeleted(testPodName)
+				})
+			})
 
-				By("Verifying correct conditions for unsuccessful remediation")
-				verifyRemediationConditions(
-					underTestFAR,
-					conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
-					conditionStatusPointer(metav1.ConditionFalse), // FenceAgentActionSucceededTypeStatus
-					conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
-				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
-				verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonNodeRemediationCompleted, utils.EventReasonNodeRemediationCompleted)
+			When("the manager restarts after the node was powered off", func() {
+				BeforeEach(func() {
+					underTestFAR.Spec.SharedParameters = noActionShareParam
+					underTestFAR.Spec.FencingMode = v1alpha1.OffOnlyFencingMode
+					mockPowerStatus = cli.PowerStatusOff
+					DeferCleanup(func() { mockPowerStatus = cli.PowerStatusOn })
+				})
+
+				It("should verify the power status, and complete the execution without powering off the node again", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					waitForExecution()
+
+					By("Restarting the manager")
+					forcedDelay = 0
+					simulateManagerRestart(underTestFAR, "previous-manager-off")
+
+					By("Completing the remediation once the node is reported as powered off")
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(meta.IsStatusConditionTrue(underTestFAR.Status.Conditions, commonConditions.SucceededType)).To(BeTrue())
+					}, "10s", pollInterval).Should(Succeed())
+					Expect(storedStatusCommand).To(ContainElement("--action=status"))
+					Expect(storedCommands).To(HaveLen(1))
+					Expect(storedCommands[0]).To(ContainElement("--action=off"))
+					Expect(underTestFAR.Status.FencedBy).To(Equal(&v1alpha1.FencingLevelStatus{Level: 1, Agent: fenceAgentIPMI}))
+					Expect(underTestFAR.Status.Execution).To(BeNil())
+					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentResumed, fmt.Sprintf(utils.EventMessageFenceAgentResumed, 1, 1))
+				})
 			})
 		})
 
@@ -490,6 +1628,15 @@ var _ = Describe("FAR Controller", func() {
 						conditionStatusPointer(metav1.ConditionFalse), // FenceAgentActionSucceededTypeStatus
 						conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
 					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+
+					By("Recording every attempt in the status")
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.Attempts).To(HaveLen(3))
+					for _, attempt := range underTestFAR.Status.Attempts {
+						Expect(attempt.Agent).To(Equal(fenceAgentIPMI))
+						Expect(attempt.TimedOut).To(BeFalse())
+						Expect(attempt.EndTime.Before(&attempt.StartTime)).To(BeFalse())
+					}
 				})
 			})
 
@@ -519,6 +1666,12 @@ var _ = Describe("FAR Controller", func() {
 						conditionStatusPointer(metav1.ConditionFalse), // FenceAgentActionSucceededTypeStatus
 						conditionStatusPointer(metav1.ConditionFalse)) // SucceededTypeStatus
 					verifyNoEvent(corev1.EventTypeNormal, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+
+					By("Recording the timed out attempt in the status")
+					Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+					Expect(underTestFAR.Status.Attempts).ToNot(BeEmpty())
+					Expect(underTestFAR.Status.Attempts[0].TimedOut).To(BeTrue())
+					Expect(underTestFAR.Status.Attempts[0].ExitCode).To(BeNil())
 				})
 			})
 		})
@@ -531,7 +1684,7 @@ var _ = Describe("FAR Controller", func() {
 
 		BeforeEach(func() {
 			farPod := createRunningPod("far-manager-test", farPodName, "")
-			DeferCleanup(k8sClient.Delete, context.Background(), farPod)
+			DeferCleanup(cleanupTestedResources, farPod)
 		})
 
 		JustBeforeEach(func() {
@@ -600,6 +1753,73 @@ var _ = Describe("FAR Controller", func() {
 				verifyEvent(corev1.EventTypeNormal, utils.EventReasonRemoveOutOfServiceTaint, utils.EventMessageRemoveOutOfServiceTaint)
 			})
 		})
+
+		When("creating FAR CR with the Automatic strategy", func() {
+			BeforeEach(func() {
+				node = utils.GetNode("", workerNode)
+				underTestFAR = getFenceAgentsRemediation(workerNode, fenceAgentIPMI, testShareParam, testNodeParam, v1alpha1.AutomaticRemediationStrategy)
+			})
+
+			When("the node has no attached volumes", func() {
+				It("should select the ResourceDeletion strategy", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyRemediationConditions(
+						underTestFAR,
+						conditionStatusPointer(metav1.ConditionFalse), // ProcessingTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue),  // FenceAgentActionSucceededTypeStatus
+						conditionStatusPointer(metav1.ConditionTrue))  // SucceededTypeStatus
+					Expect(underTestFAR.Status.RemediationStrategy).To(Equal(v1alpha1.ResourceDeletionRemediationStrategy))
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonRemediationStrategy,
+						fmt.Sprintf(utils.EventMessageRemediationStrategy, v1alpha1.ResourceDeletionRemediationStrategy))
+
+					node := &corev1.Node{}
+					Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: workerNode}, node)).To(Succeed())
+					Expect(utils.TaintExists(node.Spec.Taints, &outOfServiceTaint)).To(BeFalse(), "out-of-service taint should not exist")
+				})
+			})
+
+			When("the node has attached volumes and the out-of-service taint is supported", func() {
+				BeforeEach(func() {
+					farReconciler.OutOfServiceTaintSupported = true
+					DeferCleanup(func() { farReconciler.OutOfServiceTaintSupported = false })
+					// the node waits to be fenced until its attached volumes are set
+					stormThreshold := intstr.FromInt32(0)
+					farReconciler.StormThreshold = &stormThreshold
+					DeferCleanup(func() { farReconciler.StormThreshold = nil })
+				})
+
+				It("should select the OutOfServiceTaint strategy", func() {
+					underTestFAR = verifyPreRemediationSucceed(underTestFAR, defaultNamespace, &farRemediationTaint)
+					verifyWaitingCondition(underTestFAR, metav1.ConditionTrue, utils.RemediationStormDetected)
+					updateNodeStatus(workerNode, func(status *corev1.NodeStatus) {
+						status.VolumesAttached = []corev1.AttachedVolume{{Name: "kubernetes.io/csi/test-driver^test-volume", DevicePath: "/dev/test"}}
+					})
+					farReconciler.StormThreshold = nil
+					triggerReconcile(underTestFAR)
+
+					By("Searching for out-of-service taint")
+					Eventually(func(g Gomega) {
+						node := &corev1.Node{}
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: workerNode}, node)).To(Succeed())
+						g.Expect(utils.TaintExists(node.Spec.Taints, &outOfServiceTaint)).To(BeTrue(), "out-of-service taint should exist")
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					Eventually(func(g Gomega) {
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(underTestFAR), underTestFAR)).To(Succeed())
+						g.Expect(underTestFAR.Status.RemediationStrategy).To(Equal(v1alpha1.OutOfServiceTaintRemediationStrategy))
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+					verifyEvent(corev1.EventTypeNormal, utils.EventReasonRemediationStrategy,
+						fmt.Sprintf(utils.EventMessageRemediationStrategy, v1alpha1.OutOfServiceTaintRemediationStrategy))
+
+					By("Deleting FAR CR")
+					Expect(k8sClient.Delete(context.Background(), underTestFAR)).To(Succeed())
+					Eventually(func(g Gomega) {
+						node := &corev1.Node{}
+						g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: workerNode}, node)).To(Succeed())
+						g.Expect(utils.TaintExists(node.Spec.Taints, &outOfServiceTaint)).To(BeFalse(), "out-of-service taint should be removed")
+					}, timeoutPostRemediation, pollInterval).Should(Succeed())
+				})
+			})
+		})
 	})
 })
 
@@ -639,10 +1859,8 @@ func buildPod(containerName, podName, nodeName string) *corev1.Pod {
 	if podName == farPodName {
 		// only when we build FAR pod then we add its label
 		pod.Labels = faPodLabels
-	} else {
-		// testedPod should reside in unhealthy node
-		pod.Spec.NodeName = nodeName
 	}
+	pod.Spec.NodeName = nodeName
 	pod.Namespace = defaultNamespace
 	container := corev1.Container{
 		Name:  containerName,
@@ -798,6 +2016,55 @@ func verifyRemediationConditions(far *v1alpha1.FenceAgentsRemediation, processin
 	})
 }
 
+// verifyVerificationCondition checks the FenceAgentVerified condition of the CR
+func verifyVerificationCondition(far *v1alpha1.FenceAgentsRemediation, verifiedTypeConditionStatus *metav1.ConditionStatus) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		farCR := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), farCR)).To(Succeed())
+		verifyStatusCondition(farCR, utils.FenceAgentVerifiedType, verifiedTypeConditionStatus)
+	})
+}
+
+// verifyNodePoweredOnCondition checks the NodePoweredOn condition of the CR
+func verifyNodePoweredOnCondition(far *v1alpha1.FenceAgentsRemediation, nodePoweredOnTypeConditionStatus *metav1.ConditionStatus) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		farCR := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), farCR)).To(Succeed())
+		verifyStatusCondition(farCR, utils.NodePoweredOnType, nodePoweredOnTypeConditionStatus)
+	})
+}
+
+// verifyNodeRebootVerifiedCondition checks the NodeRebootVerified condition of the CR
+func verifyNodeRebootVerifiedCondition(far *v1alpha1.FenceAgentsRemediation, nodeRebootVerifiedTypeConditionStatus *metav1.ConditionStatus) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		farCR := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), farCR)).To(Succeed())
+		verifyStatusCondition(farCR, utils.NodeRebootVerifiedType, nodeRebootVerifiedTypeConditionStatus)
+	})
+}
+
+// updateNodeStatus updates the status of the node, since envtest has no kubelet which reports it
+func updateNodeStatus(nodeName string, update func(status *corev1.NodeStatus)) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		node := &corev1.Node{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: nodeName}, node)).To(Succeed())
+		update(&node.Status)
+		g.Expect(k8sClient.Status().Update(context.Background(), node)).To(Succeed())
+	}, timeoutPreRemediation, pollInterval).Should(Succeed())
+}
+
+// verifyWaitingCondition checks that the FAR has the Waiting condition with the expected status and reason
+func verifyWaitingCondition(far *v1alpha1.FenceAgentsRemediation, status metav1.ConditionStatus, reason utils.ConditionsChangeReason) {
+	EventuallyWithOffset(1, func(g Gomega) {
+		farCR := &v1alpha1.FenceAgentsRemediation{}
+		g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(far), farCR)).To(Succeed())
+		condition := meta.FindStatusCondition(farCR.Status.Conditions, utils.WaitingType)
+		g.Expect(condition).ToNot(BeNil())
+		g.Expect(condition.Status).To(Equal(status))
+		g.Expect(condition.Reason).To(Equal(string(reason)))
+	}, timeoutPostRemediation, pollInterval).Should(Succeed())
+}
+
 // cleanupFar deletes the FAR CR and waits until it is deleted. The function ignores if the CR is already deleted.
 func cleanupFar(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) error {
 	cr := &v1alpha1.FenceAgentsRemediation{}
@@ -827,3 +2094,32 @@ func cleanupFar(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) error
 	}, pollInterval, timeoutPostRemediation).Should(BeNil(), "CR should be deleted")
 	return nil
 }
+
+// getMetricValue returns the value of a counter, or the sample count of a histogram, with the given labels from the controller-runtime metrics registry
+func getMetricValue(name string, labels map[string]string) float64 {
+	metricFamilies, err := crmetrics.Registry.Gather()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() != name {
+			continue
+		}
+		for _, metric := range metricFamily.GetMetric() {
+			metricLabels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				metricLabels[label.GetName()] = label.GetValue()
+			}
+			isMatching := true
+			for labelName, labelValue := range labels {
+				isMatching = isMatching && metricLabels[labelName] == labelValue
+			}
+			if !isMatching {
+				continue
+			}
+			if metric.GetHistogram() != nil {
+				return float64(metric.GetHistogram().GetSampleCount())
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	commonEvents "github.com/medik8s/common/pkg/events"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+	"github.com/medik8s/fence-agents-remediation/pkg/nodetemplate"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+// FenceAgentsRemediationTemplateReconciler reconciles a FenceAgentsRemediationTemplate object, by periodically checking
+// the fencing readiness of the template's nodes when the readiness check is enabled
+type FenceAgentsRemediationTemplateReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Executor *cli.Executer
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *FenceAgentsRemediationTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// the readiness check is requeued periodically, thus status updates shouldn't trigger it
+		For(&v1alpha1.FenceAgentsRemediationTemplate{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediationtemplates,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediationtemplates/status,verbs=get;update;patch
+
+// Reconcile checks the fencing readiness of the template's nodes once in the readiness check interval, or when the template changes,
+// and reports it in the template status
+func (r *FenceAgentsRemediationTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	farTemplate := &v1alpha1.FenceAgentsRemediationTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, farTemplate); err != nil {
+		if apiErrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to get FenceAgentsRemediationTemplate CR")
+		return ctrl.Result{}, err
+	}
+
+	readinessCheck := farTemplate.Spec.ReadinessCheck
+	if readinessCheck == nil {
+		if farTemplate.Status.LastCheckTime == nil {
+			return ctrl.Result{}, nil
+		}
+		// the readiness check was disabled, thus its results are outdated
+		r.Log.Info("Fencing readiness check is disabled, clearing its results", "Template Name", farTemplate.Name)
+		base := farTemplate.DeepCopy()
+		farTemplate.Status = v1alpha1.FenceAgentsRemediationTemplateStatus{}
+		return ctrl.Result{}, r.Client.Status().Patch(ctx, farTemplate, client.MergeFrom(base))
+	}
+
+	if lastCheckTime := farTemplate.Status.LastCheckTime; lastCheckTime != nil && farTemplate.Status.ObservedGeneration == farTemplate.Generation {
+		if nextCheck := time.Until(lastCheckTime.Add(readinessCheck.Interval.Duration)); nextCheck > 0 {
+			return ctrl.Result{RequeueAfter: nextCheck}, nil
+		}
+	}
+
+	nodeNames, err := r.getTemplateNodeNames(ctx, farTemplate)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the nodes of FenceAgentsRemediationTemplate CR", "Template Name", farTemplate.Name)
+		return ctrl.Result{}, err
+	}
+	checkedNodeNames := getNodesToCheck(farTemplate, nodeNames, time.Now())
+	r.Log.Info("Check the fencing readiness of the template's nodes", "Template Name", farTemplate.Name, "Nodes", len(nodeNames), "Checked Nodes", len(checkedNodeNames))
+	checker := &fencingChecker{Client: r.Client, Log: r.Log, Executor: r.Executor}
+	results := checker.checkNodes(ctx, farTemplate, checkedNodeNames, readinessCheck.MaxConcurrentChecks)
+
+	// the results are patched rather than updated, since a conflict with another status update would throw away the results of
+	// contacting every fencing device
+	base := farTemplate.DeepCopy()
+	r.updateReadiness(farTemplate, nodeNames, checkedNodeNames, results)
+	if err := r.Client.Status().Patch(ctx, farTemplate, client.MergeFrom(base)); err != nil {
+		r.Log.Error(err, "Failed to patch FenceAgentsRemediationTemplate status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: readinessCheck.Interval.Duration}, nil
+}
+
+// getNodesToCheck returns the template's nodes whose fencing readiness should be checked, i.e. all of them when the template has changed
+// since the last check, and otherwise the nodes which weren't checked yet or whose last check is older than the readiness check interval
+func getNodesToCheck(farTemplate *v1alpha1.FenceAgentsRemediationTemplate, nodeNames []string, now time.Time) []string {
+	if farTemplate.Status.ObservedGeneration != farTemplate.Generation {
+		return nodeNames
+	}
+	lastCheckTimes := make(map[v1alpha1.NodeName]*metav1.Time, len(farTemplate.Status.Nodes))
+	for _, nodeReadiness := range farTemplate.Status.Nodes {
+		lastCheckTimes[nodeReadiness.NodeName] = nodeReadiness.LastCheckTime
+	}
+	var nodesToCheck []string
+	for _, nodeName := range nodeNames {
+		lastCheckTime := lastCheckTimes[v1alpha1.NodeName(nodeName)]
+		if lastCheckTime == nil || !now.Before(lastCheckTime.Add(farTemplate.Spec.ReadinessCheck.Interval.Duration)) {
+			nodesToCheck = append(nodesToCheck, nodeName)
+		}
+	}
+	return nodesToCheck
+}
+
+// updateReadiness sets the fencing readiness of the checked nodes in the template status, keeps the still fresh readiness of the other
+// nodes, and emits an event for each node which became not ready
+func (r *FenceAgentsRemediationTemplateReconciler) updateReadiness(farTemplate *v1alpha1.FenceAgentsRemediationTemplate, nodeNames, checkedNodeNames []string, results []v1alpha1.NodeFencingTestResult) {
+	now := metav1.Now()
+	previousNodes := make(map[v1alpha1.NodeName]v1alpha1.NodeFencingReadiness, len(farTemplate.Status.Nodes))
+	for _, nodeReadiness := range farTemplate.Status.Nodes {
+		previousNodes[nodeReadiness.NodeName] = nodeReadiness
+	}
+	isChecked := make(map[string]bool, len(checkedNodeNames))
+	for _, nodeName := range checkedNodeNames {
+		isChecked[nodeName] = true
+	}
+	nodesErrors := make(map[v1alpha1.NodeName][]string)
+	for _, result := range results {
+		if !result.Reachable {
+			nodesErrors[result.NodeName] = append(nodesErrors[result.NodeName], fmt.Sprintf("level %d (%s): %s", result.Level, result.Agent, result.Error))
+		}
+	}
+
+	// nodes which are no longer covered by the template are removed from the status
+	nodes := make([]v1alpha1.NodeFencingReadiness, 0, len(nodeNames))
+	notReadyNodes := 0
+	for _, nodeName := range nodeNames {
+		nodeReadiness := previousNodes[v1alpha1.NodeName(nodeName)]
+		if !isChecked[nodeName] {
+			if meta.IsStatusConditionFalse(nodeReadiness.Conditions, utils.FencingReadyType) {
+				notReadyNodes++
+			}
+			nodes = append(nodes, nodeReadiness)
+			continue
+		}
+		nodeReadiness.NodeName = v1alpha1.NodeName(nodeName)
+		nodeReadiness.LastCheckTime = &now
+		nodeReadiness.LastError = strings.Join(nodesErrors[nodeReadiness.NodeName], "; ")
+
+		condition := metav1.Condition{
+			Type:    utils.FencingReadyType,
+			Status:  metav1.ConditionTrue,
+			Reason:  utils.FencingDevicesReachableReason,
+			Message: utils.FencingReadyConditionMessage,
+		}
+		if nodeReadiness.LastError != "" {
+			notReadyNodes++
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = utils.FencingDevicesUnreachableReason
+			condition.Message = utils.FencingNotReadyConditionMessage
+			if !meta.IsStatusConditionFalse(nodeReadiness.Conditions, utils.FencingReadyType) {
+				r.Log.Info("Node isn't ready for fencing", "Template Name", farTemplate.Name, "Node Name", nodeName, "Error", nodeReadiness.LastError)
+				commonEvents.WarningEvent(r.Recorder, farTemplate, utils.EventReasonNodeFencingNotReady, fmt.Sprintf(utils.EventMessageNodeFencingNotReady, nodeName))
+			}
+		}
+		meta.SetStatusCondition(&nodeReadiness.Conditions, condition)
+		nodes = append(nodes, nodeReadiness)
+	}
+	farTemplate.Status.Nodes = nodes
+
+	condition := metav1.Condition{
+		Type:    utils.FencingReadyType,
+		Status:  metav1.ConditionTrue,
+		Reason:  utils.FencingDevicesReachableReason,
+		Message: utils.AllNodesFencingReadyConditionMessage,
+	}
+	if len(nodeNames) == 0 {
+		// the readiness is unknown rather than true, since the template might still fail to fence any node
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = utils.NoNodesCheckedReason
+		condition.Message = utils.NoNodesFencingCheckedConditionMessage
+	} else if notReadyNodes > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = utils.FencingDevicesUnreachableReason
+		condition.Message = fmt.Sprintf(utils.NodesNotFencingReadyConditionMessage, notReadyNodes, len(nodeNames))
+	}
+	meta.SetStatusCondition(&farTemplate.Status.Conditions, condition)
+	farTemplate.Status.LastCheckTime = &now
+	farTemplate.Status.ObservedGeneration = farTemplate.Generation
+}
+
+// getTemplateNodeNames returns the names of the nodes which are covered by any of the template's fencing levels, i.e. the nodes which have
+// node parameters or node secrets, and the nodes whose parameters are selected or derived by the fencing levels
+func (r *FenceAgentsRemediationTemplateReconciler) getTemplateNodeNames(ctx context.Context, farTemplate *v1alpha1.FenceAgentsRemediationTemplate) ([]string, error) {
+	far := &v1alpha1.FenceAgentsRemediation{Spec: farTemplate.Spec.Template.Spec}
+	nodeNames := make(map[v1alpha1.NodeName]bool)
+	addNodeParameters := func(nodeParameters map[v1alpha1.ParameterName]map[v1alpha1.NodeName]string) {
+		for _, nodeMap := range nodeParameters {
+			for nodeName := range nodeMap {
+				nodeNames[nodeName] = true
+			}
+		}
+	}
+	fencingLevels := getFencingLevels(far)
+	isNodeListRequired := false
+	for _, fencingLevel := range fencingLevels {
+		addNodeParameters(fencingLevel.NodeParameters)
+		for _, device := range fencingLevel.Devices {
+			addNodeParameters(device.NodeParameters)
+		}
+		for nodeName := range fencingLevel.NodeSecretNames {
+			nodeNames[nodeName] = true
+		}
+		if len(fencingLevel.NodeGroups) > 0 || fencingLevel.ParametersSource != "" || hasParameterTemplates(fencingLevel) {
+			isNodeListRequired = true
+		}
+	}
+
+	if isNodeListRequired {
+		nodes := &corev1.NodeList{}
+		if err := r.List(ctx, nodes); err != nil {
+			return nil, fmt.Errorf("failed to list the nodes: %w", err)
+		}
+		for i := range nodes.Items {
+			for _, fencingLevel := range fencingLevels {
+				if isNodeCoveredByLevel(fencingLevel, &nodes.Items[i]) {
+					nodeNames[v1alpha1.NodeName(nodes.Items[i].Name)] = true
+					break
+				}
+			}
+		}
+	}
+
+	sortedNodeNames := make([]string, 0, len(nodeNames))
+	for nodeName := range nodeNames {
+		sortedNodeNames = append(sortedNodeNames, string(nodeName))
+	}
+	sort.Strings(sortedNodeNames)
+	return sortedNodeNames, nil
+}
+
+// isNodeCoveredByLevel checks whether the node is selected by a node group of the fencing level, or whether its parameters are derived
+// from the node by the fencing level, by parameter templates or by a parameters source which can derive them from this node
+func isNodeCoveredByLevel(fencingLevel v1alpha1.FencingLevel, node *corev1.Node) bool {
+	if nodeGroup, err := getNodeGroup(fencingLevel, node); err == nil && nodeGroup != nil {
+		return true
+	}
+	switch fencingLevel.ParametersSource {
+	case v1alpha1.BareMetalHostParametersSource:
+		if _, isFound := node.Annotations[machineAnnotation]; isFound {
+			return true
+		}
+	case v1alpha1.ProviderIDParametersSource:
+		if node.Spec.ProviderID != "" {
+			return true
+		}
+	}
+	// templates can be resolved against any node
+	return hasParameterTemplates(fencingLevel)
+}
+
+// hasParameterTemplates checks whether any of the shared or node group parameters of the fencing level is a node template
+func hasParameterTemplates(fencingLevel v1alpha1.FencingLevel) bool {
+	for _, paramVal := range fencingLevel.SharedParameters {
+		if nodetemplate.IsTemplate(paramVal) {
+			return true
+		}
+	}
+	for _, nodeGroup := range fencingLevel.NodeGroups {
+		for _, paramVal := range nodeGroup.Parameters {
+			if nodetemplate.IsTemplate(paramVal) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2023.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+var _ = Describe("FAR Template Controller", func() {
+	var farTemplate *v1alpha1.FenceAgentsRemediationTemplate
+
+	BeforeEach(func() {
+		clearEvents()
+		farTemplate = &v1alpha1.FenceAgentsRemediationTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "far-template-readiness", Namespace: defaultNamespace},
+			Spec: v1alpha1.FenceAgentsRemediationTemplateSpec{
+				Template: v1alpha1.FenceAgentsRemediationTemplateResource{
+					Spec: v1alpha1.FenceAgentsRemediationSpec{
+						Agent:            fenceAgentIPMI,
+						SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.1", "--username": "admin"},
+						NodeParameters: map[v1alpha1.ParameterName]map[v1alpha1.NodeName]string{
+							"--ipport": {workerNode: "6233", "worker-1": "6234"},
+						},
+						RetryCount:          5,
+						RetryInterval:       metav1.Duration{Duration: 5 * time.Second},
+						Timeout:             metav1.Duration{Duration: 60 * time.Second},
+						RemediationStrategy: v1alpha1.ResourceDeletionRemediationStrategy,
+					},
+				},
+				ReadinessCheck: &v1alpha1.FencingReadinessCheck{
+					Interval:            metav1.Duration{Duration: time.Hour},
+					MaxConcurrentChecks: 1,
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		Expect(k8sClient.Create(context.Background(), farTemplate)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, context.Background(), farTemplate)
+	})
+
+	getCheckedTemplate := func() *v1alpha1.FenceAgentsRemediationTemplate {
+		checkedTemplate := &v1alpha1.FenceAgentsRemediationTemplate{}
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(farTemplate), checkedTemplate)).To(Succeed())
+			g.Expect(checkedTemplate.Status.LastCheckTime).ToNot(BeNil())
+		}, timeoutPostRemediation, pollInterval).Should(Succeed())
+		return checkedTemplate
+	}
+
+	When("the fence agent reports the power status of the template's nodes", func() {
+		It("should report all the nodes as ready for fencing", func() {
+			checkedTemplate := getCheckedTemplate()
+			Expect(meta.IsStatusConditionTrue(checkedTemplate.Status.Conditions, utils.FencingReadyType)).To(BeTrue())
+			Expect(checkedTemplate.Status.Nodes).To(HaveLen(2))
+			for _, nodeReadiness := range checkedTemplate.Status.Nodes {
+				Expect(meta.IsStatusConditionTrue(nodeReadiness.Conditions, utils.FencingReadyType)).To(BeTrue())
+				Expect(nodeReadiness.LastCheckTime).ToNot(BeNil())
+				Expect(nodeReadiness.LastError).To(BeEmpty())
+			}
+			Expect(checkedTemplate.Status.Nodes[0].NodeName).To(Equal(v1alpha1.NodeName(workerNode)))
+			Expect(checkedTemplate.Status.ObservedGeneration).To(Equal(checkedTemplate.Generation))
+		})
+
+		It("should clear the results once the readiness check is disabled", func() {
+			checkedTemplate := getCheckedTemplate()
+			checkedTemplate.Spec.ReadinessCheck = nil
+			Expect(k8sClient.Update(context.Background(), checkedTemplate)).To(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(farTemplate), checkedTemplate)).To(Succeed())
+				g.Expect(checkedTemplate.Status.LastCheckTime).To(BeNil())
+				g.Expect(checkedTemplate.Status.Nodes).To(BeEmpty())
+			}, timeoutPostRemediation, pollInterval).Should(Succeed())
+		})
+	})
+
+	When("the nodes of the template are selected by a node group", func() {
+		const rackNode = "worker-rack-a"
+
+		BeforeEach(func() {
+			farTemplate.Spec.Template.Spec.NodeParameters = nil
+			farTemplate.Spec.Template.Spec.NodeGroups = []v1alpha1.NodeGroup{{
+				Name:         "rack-a",
+				NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"rack": "a"}},
+				Parameters:   map[v1alpha1.ParameterName]string{"--ipport": "6233"},
+			}}
+			node := utils.GetNode("", rackNode)
+			node.Labels = map[string]string{"rack": "a"}
+			Expect(k8sClient.Create(context.Background(), node)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, context.Background(), node)
+		})
+
+		It("should check the selected nodes", func() {
+			checkedTemplate := getCheckedTemplate()
+			Expect(meta.IsStatusConditionTrue(checkedTemplate.Status.Conditions, utils.FencingReadyType)).To(BeTrue())
+			Expect(checkedTemplate.Status.Nodes).To(HaveLen(1))
+			Expect(checkedTemplate.Status.Nodes[0].NodeName).To(Equal(v1alpha1.NodeName(rackNode)))
+		})
+	})
+
+	When("the template doesn't cover any node", func() {
+		BeforeEach(func() {
+			farTemplate.Spec.Template.Spec.NodeParameters = nil
+		})
+
+		It("should report the readiness as unknown", func() {
+			checkedTemplate := getCheckedTemplate()
+			condition := meta.FindStatusCondition(checkedTemplate.Status.Conditions, utils.FencingReadyType)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(condition.Reason).To(Equal(utils.NoNodesCheckedReason))
+			Expect(checkedTemplate.Status.Nodes).To(BeEmpty())
+		})
+	})
+
+	When("the fence agent of a fallback level can't report the power status", func() {
+		BeforeEach(func() {
+			farTemplate.Spec.Template.Spec.FallbackLevels = []v1alpha1.FencingLevel{{
+				Agent:            fenceAgentRedfish,
+				SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.2"},
+				NodeSecretNames:  map[v1alpha1.NodeName]string{"worker-2": "worker-2-secret"},
+				RetryCount:       5,
+				RetryInterval:    metav1.Duration{Duration: 5 * time.Second},
+				Timeout:          metav1.Duration{Duration: 60 * time.Second},
+			}}
+			mockFailingAgent = fenceAgentRedfish
+			DeferCleanup(func() { mockFailingAgent = "" })
+		})
+
+		It("should report the nodes as not ready for fencing", func() {
+			checkedTemplate := getCheckedTemplate()
+			condition := meta.FindStatusCondition(checkedTemplate.Status.Conditions, utils.FencingReadyType)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(utils.FencingDevicesUnreachableReason))
+			Expect(condition.Message).To(Equal(fmt.Sprintf(utils.NodesNotFencingReadyConditionMessage, 3, 3)))
+
+			// the node secret of the fallback level covers another node
+			Expect(checkedTemplate.Status.Nodes).To(HaveLen(3))
+			for _, nodeReadiness := range checkedTemplate.Status.Nodes {
+				Expect(meta.IsStatusConditionFalse(nodeReadiness.Conditions, utils.FencingReadyType)).To(BeTrue())
+				Expect(nodeReadiness.LastError).To(ContainSubstring("level 2 (fence_redfish)"))
+				Expect(nodeReadiness.LastError).To(ContainSubstring("Failed: Unable to connect"))
+			}
+			verifyEvent(corev1.EventTypeWarning, utils.EventReasonNodeFencingNotReady, fmt.Sprintf(utils.EventMessageNodeFencingNotReady, workerNode))
+		})
+	})
+})
+
+var _ = Describe("Nodes to check", func() {
+	now := time.Now()
+	farTemplate := &v1alpha1.FenceAgentsRemediationTemplate{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec: v1alpha1.FenceAgentsRemediationTemplateSpec{
+			ReadinessCheck: &v1alpha1.FencingReadinessCheck{Interval: metav1.Duration{Duration: time.Hour}},
+		},
+		Status: v1alpha1.FenceAgentsRemediationTemplateStatus{
+			ObservedGeneration: 2,
+			Nodes: []v1alpha1.NodeFencingReadiness{
+				{NodeName: "worker-0", LastCheckTime: &metav1.Time{Time: now.Add(-time.Minute)}},
+				{NodeName: "worker-1", LastCheckTime: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+			},
+		},
+	}
+	nodeNames := []string{"worker-0", "worker-1", "worker-2"}
+
+	It("should skip the nodes whose last check is still fresh", func() {
+		Expect(getNodesToCheck(farTemplate, nodeNames, now)).To(Equal([]string{"worker-1", "worker-2"}))
+	})
+
+	It("should check all the nodes once the template has changed", func() {
+		changedTemplate := farTemplate.DeepCopy()
+		changedTemplate.Generation = 3
+		Expect(getNodesToCheck(changedTemplate, nodeNames, now)).To(Equal(nodeNames))
+	})
+})
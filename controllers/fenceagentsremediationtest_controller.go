@@ -0,0 +1,192 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/go-logr/logr"
+	commonEvents "github.com/medik8s/common/pkg/events"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+const (
+	// maxConcurrentNodeTests is the maximum number of nodes which are tested at the same time
+	maxConcurrentNodeTests = 10
+)
+
+// FenceAgentsRemediationTestReconciler reconciles a FenceAgentsRemediationTest object
+type FenceAgentsRemediationTestReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Executor *cli.Executer
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *FenceAgentsRemediationTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// the test records its own progress in its status, thus status updates shouldn't trigger it again
+		For(&v1alpha1.FenceAgentsRemediationTest{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediationtests,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediationtests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=fence-agents-remediation.medik8s.io,resources=fenceagentsremediationtemplates,verbs=get;list;watch
+
+// Reconcile runs the fence agent status action for each node selected by the FenceAgentsRemediationTest, with the same
+// parameters which a FenceAgentsRemediation of the template would use, and reports the results in the test status.
+// A test runs once, and it can be run again by recreating it.
+func (r *FenceAgentsRemediationTestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.Info("Begin FenceAgentsRemediationTest Reconcile")
+	defer r.Log.Info("Finish FenceAgentsRemediationTest Reconcile")
+
+	farTest := &v1alpha1.FenceAgentsRemediationTest{}
+	if err := r.Get(ctx, req.NamespacedName, farTest); err != nil {
+		if apiErrors.IsNotFound(err) {
+			r.Log.Info("FenceAgentsRemediationTest CR was not found", "CR Name", req.Name, "CR Namespace", req.Namespace)
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "Failed to get FenceAgentsRemediationTest CR")
+		return ctrl.Result{}, err
+	}
+	if farTest.Status.CompletionTime != nil {
+		r.Log.Info("FenceAgentsRemediationTest CR has already completed", "CR Name", req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	base := farTest.DeepCopy()
+	farTemplate := &v1alpha1.FenceAgentsRemediationTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: farTest.Spec.TemplateName, Namespace: farTest.Namespace}, farTemplate); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to get FenceAgentsRemediationTemplate CR", "Template Name", farTest.Spec.TemplateName)
+			return ctrl.Result{}, err
+		}
+		farTest.Status.Error = fmt.Sprintf("FenceAgentsRemediationTemplate %s was not found", farTest.Spec.TemplateName)
+		return ctrl.Result{}, r.completeTest(ctx, farTest, base)
+	}
+
+	nodes, err := r.getTestedNodes(ctx, farTest)
+	if err != nil {
+		r.Log.Error(err, "Failed to get the tested nodes", "CR Name", req.Name)
+		return ctrl.Result{}, err
+	}
+
+	if farTest.Status.StartTime == nil {
+		// the start is recorded before any fencing device is contacted, hence a conflict retries the test before it has run, rather than after
+		now := metav1.Now()
+		farTest.Status.StartTime = &now
+		if err := r.Client.Status().Update(ctx, farTest); err != nil {
+			if !apiErrors.IsConflict(err) {
+				r.Log.Error(err, "Failed to update FenceAgentsRemediationTest status")
+			}
+			return ctrl.Result{}, err
+		}
+		base = farTest.DeepCopy()
+	} else {
+		r.Log.Info("FenceAgentsRemediationTest CR was interrupted before it has completed, thus it runs again", "CR Name", req.Name)
+	}
+
+	r.Log.Info("Test the fence agent parameters", "Template Name", farTemplate.Name, "Nodes", len(nodes))
+	checker := &fencingChecker{Client: r.Client, Log: r.Log, Executor: r.Executor}
+	farTest.Status.Results = checker.checkNodes(ctx, farTemplate, nodes, maxConcurrentNodeTests)
+	farTest.Status.ReachableNodes, farTest.Status.UnreachableNodes = countReachableNodes(farTest.Status.Results)
+	return ctrl.Result{}, r.completeTest(ctx, farTest, base)
+}
+
+// getTestedNodes returns the names of the nodes which are selected by the node selector or by their names, or all the nodes if there is no selection
+func (r *FenceAgentsRemediationTestReconciler) getTestedNodes(ctx context.Context, farTest *v1alpha1.FenceAgentsRemediationTest) ([]string, error) {
+	selector := labels.Nothing()
+	if farTest.Spec.NodeSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(farTest.Spec.NodeSelector); err != nil {
+			return nil, err
+		}
+	} else if len(farTest.Spec.NodeNames) == 0 {
+		selector = labels.Everything()
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	var nodeNames []string
+	for _, node := range nodes.Items {
+		if selector.Matches(labels.Set(node.Labels)) || slices.Contains(farTest.Spec.NodeNames, v1alpha1.NodeName(node.Name)) {
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+	sort.Strings(nodeNames)
+	return nodeNames, nil
+}
+
+// countReachableNodes counts the nodes whose fencing levels are all reachable, and the nodes which have an unreachable fencing level
+func countReachableNodes(results []v1alpha1.NodeFencingTestResult) (int, int) {
+	isNodeReachable := make(map[v1alpha1.NodeName]bool)
+	for _, result := range results {
+		if isReachable, exist := isNodeReachable[result.NodeName]; !exist || isReachable {
+			isNodeReachable[result.NodeName] = result.Reachable
+		}
+	}
+	reachable := 0
+	for _, isReachable := range isNodeReachable {
+		if isReachable {
+			reachable++
+		}
+	}
+	return reachable, len(isNodeReachable) - reachable
+}
+
+// completeTest sets the test completion time, patches its status from base and emits an event with its outcome.
+// The status is patched rather than updated, since a conflict would otherwise throw away the results and run the test again.
+func (r *FenceAgentsRemediationTestReconciler) completeTest(ctx context.Context, farTest, base *v1alpha1.FenceAgentsRemediationTest) error {
+	now := metav1.Now()
+	farTest.Status.CompletionTime = &now
+	if err := r.Client.Status().Patch(ctx, farTest, client.MergeFrom(base)); err != nil {
+		r.Log.Error(err, "Failed to patch FenceAgentsRemediationTest status")
+		return err
+	}
+
+	switch {
+	case farTest.Status.Error != "":
+		commonEvents.WarningEvent(r.Recorder, farTest, utils.EventReasonFencingTestUnreachable, farTest.Status.Error)
+	case farTest.Status.UnreachableNodes > 0:
+		commonEvents.WarningEvent(r.Recorder, farTest, utils.EventReasonFencingTestUnreachable, utils.EventMessageFencingTestUnreachable)
+	default:
+		commonEvents.NormalEvent(r.Recorder, farTest, utils.EventReasonFencingTestCompleted, utils.EventMessageFencingTestCompleted)
+	}
+	r.Log.Info("FenceAgentsRemediationTest CR has completed", "CR Name", farTest.Name,
+		"Reachable Nodes", farTest.Status.ReachableNodes, "Unreachable Nodes", farTest.Status.UnreachableNodes, "Error", farTest.Status.Error)
+	return nil
+}
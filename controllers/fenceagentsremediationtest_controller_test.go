@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+const (
+	fenceAgentRedfish = "fence_redfish"
+	farTemplateName   = "far-template-test"
+	farTestName       = "far-test"
+)
+
+var _ = Describe("FAR Test Controller", func() {
+	var (
+		farTemplate *v1alpha1.FenceAgentsRemediationTemplate
+		farTest     *v1alpha1.FenceAgentsRemediationTest
+	)
+
+	BeforeEach(func() {
+		storedStatusCommand = storedStatusCommand[:0]
+		clearEvents()
+
+		for _, nodeName := range []string{workerNode, "worker-1"} {
+			node := utils.GetNode("", nodeName)
+			Expect(k8sClient.Create(context.Background(), node)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, context.Background(), node)
+		}
+
+		farTemplate = &v1alpha1.FenceAgentsRemediationTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: farTemplateName, Namespace: defaultNamespace},
+			Spec: v1alpha1.FenceAgentsRemediationTemplateSpec{
+				Template: v1alpha1.FenceAgentsRemediationTemplateResource{
+					Spec: v1alpha1.FenceAgentsRemediationSpec{
+						Agent:            fenceAgentIPMI,
+						SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.1", "--username": "admin"},
+						NodeParameters: map[v1alpha1.ParameterName]map[v1alpha1.NodeName]string{
+							"--ipport": {workerNode: "6233", "worker-1": "6234"},
+						},
+						RetryCount:          5,
+						RetryInterval:       metav1.Duration{Duration: 5 * time.Second},
+						Timeout:             metav1.Duration{Duration: 60 * time.Second},
+						RemediationStrategy: v1alpha1.ResourceDeletionRemediationStrategy,
+					},
+				},
+			},
+		}
+		farTest = &v1alpha1.FenceAgentsRemediationTest{
+			ObjectMeta: metav1.ObjectMeta{Name: farTestName, Namespace: defaultNamespace},
+			Spec:       v1alpha1.FenceAgentsRemediationTestSpec{TemplateName: farTemplateName},
+		}
+	})
+
+	JustBeforeEach(func() {
+		Expect(k8sClient.Create(context.Background(), farTemplate)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, context.Background(), farTemplate)
+		Expect(k8sClient.Create(context.Background(), farTest)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, context.Background(), farTest)
+	})
+
+	getCompletedTest := func() *v1alpha1.FenceAgentsRemediationTest {
+		completedTest := &v1alpha1.FenceAgentsRemediationTest{}
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(farTest), completedTest)).To(Succeed())
+			g.Expect(completedTest.Status.CompletionTime).ToNot(BeNil())
+		}, timeoutPostRemediation, pollInterval).Should(Succeed())
+		return completedTest
+	}
+
+	When("the fence agent reports the power status of the selected node", func() {
+		BeforeEach(func() {
+			farTest.Spec.NodeNames = []v1alpha1.NodeName{workerNode}
+		})
+
+		It("should run the status action with the node's parameters, and report the node as reachable", func() {
+			completedTest := getCompletedTest()
+			Expect(completedTest.Status.Results).To(HaveLen(1))
+			result := completedTest.Status.Results[0]
+			Expect(result.NodeName).To(Equal(v1alpha1.NodeName(workerNode)))
+			Expect(result.Level).To(Equal(1))
+			Expect(result.Agent).To(Equal(fenceAgentIPMI))
+			Expect(result.Reachable).To(BeTrue())
+			Expect(result.PowerStatus).To(Equal(cli.PowerStatusOn))
+			Expect(result.Error).To(BeEmpty())
+			Expect(completedTest.Status.ReachableNodes).To(Equal(1))
+			Expect(completedTest.Status.UnreachableNodes).To(BeZero())
+			// the start is recorded before the test runs
+			Expect(completedTest.Status.StartTime).ToNot(BeNil())
+			Expect(completedTest.Status.CompletionTime.Before(completedTest.Status.StartTime)).To(BeFalse())
+
+			Expect(storedStatusCommand).To(ConsistOf(fenceAgentIPMI, "--action=status", "--ip=192.168.111.1", "--username=admin", "--ipport=6233"))
+			verifyEvent(corev1.EventTypeNormal, utils.EventReasonFencingTestCompleted, utils.EventMessageFencingTestCompleted)
+		})
+	})
+
+	When("the fence agent of a fallback level can't report the power status", func() {
+		BeforeEach(func() {
+			farTemplate.Spec.Template.Spec.FallbackLevels = []v1alpha1.FencingLevel{{
+				Agent:            fenceAgentRedfish,
+				SharedParameters: map[v1alpha1.ParameterName]string{"--ip": "192.168.111.2"},
+				RetryCount:       5,
+				RetryInterval:    metav1.Duration{Duration: 5 * time.Second},
+				Timeout:          metav1.Duration{Duration: 60 * time.Second},
+			}}
+			mockFailingAgent = fenceAgentRedfish
+			DeferCleanup(func() { mockFailingAgent = "" })
+		})
+
+		It("should test all the nodes and report them as unreachable", func() {
+			completedTest := getCompletedTest()
+			Expect(completedTest.Status.Results).To(HaveLen(4))
+			for _, result := range completedTest.Status.Results {
+				if result.Agent == fenceAgentRedfish {
+					Expect(result.Level).To(Equal(2))
+					Expect(result.Reachable).To(BeFalse())
+					Expect(result.Error).To(ContainSubstring("Failed: Unable to connect"))
+				} else {
+					Expect(result.Reachable).To(BeTrue())
+				}
+			}
+			Expect(completedTest.Status.ReachableNodes).To(BeZero())
+			Expect(completedTest.Status.UnreachableNodes).To(Equal(2))
+			verifyEvent(corev1.EventTypeWarning, utils.EventReasonFencingTestUnreachable, utils.EventMessageFencingTestUnreachable)
+		})
+	})
+
+	When("the template doesn't exist", func() {
+		BeforeEach(func() {
+			farTest.Spec.TemplateName = "missing-template"
+		})
+
+		It("should report the missing template", func() {
+			completedTest := getCompletedTest()
+			Expect(completedTest.Status.Results).To(BeEmpty())
+			Expect(completedTest.Status.Error).To(ContainSubstring("missing-template"))
+		})
+	})
+})
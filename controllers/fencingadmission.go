@@ -0,0 +1,207 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonConditions "github.com/medik8s/common/pkg/conditions"
+	commonEvents "github.com/medik8s/common/pkg/events"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+const (
+	// fencingWaitingRequeueInterval is the interval for checking again whether a node which is waiting to be fenced can be fenced
+	fencingWaitingRequeueInterval = 10 * time.Second
+	// fencingHandOffTimeout is the time after which the manager pod is deleted again, when it still runs on the node which is handed off
+	fencingHandOffTimeout = 5 * time.Minute
+)
+
+// getFencingWaitingReason checks the etcd quorum protection, the cluster-wide fencing limits and the node of the manager, and returns the reason
+// and the event message when the node has to wait before it is fenced, or an empty reason when it can be fenced now
+func (r *FenceAgentsRemediationReconciler) getFencingWaitingReason(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) (utils.ConditionsChangeReason, string, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return "", "", fmt.Errorf("failed to list the nodes: %w", err)
+	}
+	farList := &v1alpha1.FenceAgentsRemediationList{}
+	if err := r.List(ctx, farList); err != nil {
+		return "", "", fmt.Errorf("failed to list the FenceAgentsRemediation CRs: %w", err)
+	}
+
+	if reason, message := r.checkControlPlaneQuorum(far, nodes.Items, farList.Items); reason != "" {
+		return reason, message, nil
+	}
+
+	// the nodes which are already being fenced keep being fenced during a remediation storm, and only new fencing is paused
+	if r.StormThreshold != nil {
+		threshold, err := intstr.GetScaledValueFromIntOrPercent(r.StormThreshold, len(nodes.Items), true)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remediation storm threshold %s: %w", r.StormThreshold.String(), err)
+		}
+		if unhealthyNodes := len(getUnhealthyNodeNames(farList.Items)); unhealthyNodes > threshold {
+			r.Log.Info("Remediation storm was detected, thus the node isn't fenced", "Node Name", getNodeName(far), "Unhealthy Nodes", unhealthyNodes,
+				"Nodes", len(nodes.Items), "Threshold", threshold)
+			return utils.RemediationStormDetected, fmt.Sprintf(utils.EventMessageRemediationStorm, unhealthyNodes, len(nodes.Items), threshold), nil
+		}
+	}
+
+	if r.MaxConcurrentFencing != nil {
+		maxFencing, err := intstr.GetScaledValueFromIntOrPercent(r.MaxConcurrentFencing, len(nodes.Items), false)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid maximal concurrent fencing %s: %w", r.MaxConcurrentFencing.String(), err)
+		}
+		// at least one node can always be fenced, otherwise a small percentage of a small cluster would block all the remediations
+		maxFencing = max(maxFencing, 1)
+		fencingNodes := 0
+		for i := range farList.Items {
+			if farList.Items[i].UID != far.UID && r.isFencing(&farList.Items[i]) {
+				fencingNodes++
+			}
+		}
+		if fencingNodes >= maxFencing {
+			r.Log.Info("Fencing concurrency limit was reached, thus the node isn't fenced", "Node Name", getNodeName(far), "Fencing Nodes", fencingNodes,
+				"Max Concurrent Fencing", maxFencing)
+			return utils.FencingConcurrencyLimitReached, fmt.Sprintf(utils.EventMessageFencingQueued, fencingNodes, maxFencing), nil
+		}
+	}
+	return r.handOffFencing(ctx, far)
+}
+
+// handOffFencing checks whether the manager runs on the node, which would kill the manager in the middle of the remediation, and returns
+// the waiting reason and the event message if it does. The manager pod is deleted then, and since the node is already tainted with the FAR
+// NoExecute taint, the manager is rescheduled to another node, or a standby replica on another node takes over the remediation.
+// The manager pod is deleted again only when it still runs on the node after fencingHandOffTimeout, e.g. when it was rescheduled to the same node.
+func (r *FenceAgentsRemediationReconciler) handOffFencing(ctx context.Context, far *v1alpha1.FenceAgentsRemediation) (utils.ConditionsChangeReason, string, error) {
+	nodeName := getNodeName(far)
+	podNamespace, err := utils.GetDeploymentNamespace()
+	if r.PodName == "" || err != nil {
+		// e.g. when the manager runs outside the cluster
+		r.Log.Info("The manager pod is unknown, thus assuming it doesn't run on the node", "Node Name", nodeName)
+		return "", "", nil
+	}
+	// only the pod of this manager is checked, since the pods of the other replicas don't run the remediation
+	managerPod := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: podNamespace, Name: r.PodName}, managerPod); err != nil {
+		if apiErrors.IsNotFound(err) {
+			r.Log.Info("The manager pod wasn't found, thus assuming it doesn't run on the node", "Node Name", nodeName, "Manager Pod", r.PodName)
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to get the manager pod %s: %w", r.PodName, err)
+	}
+	if managerPod.Spec.NodeName != nodeName {
+		return "", "", nil
+	}
+
+	if handOffTime := far.Status.FencingHandOffTime; handOffTime == nil {
+		r.Log.Info("The manager runs on the node, thus the node fencing is handed off to a manager on another node", "Node Name", nodeName,
+			"Manager Pod", managerPod.Name)
+	} else if time.Since(handOffTime.Time) >= fencingHandOffTimeout {
+		r.Log.Info("The manager still runs on the node after the node fencing was handed off, thus the manager pod is deleted again", "Node Name", nodeName,
+			"Manager Pod", managerPod.Name, "Hand Off Time", handOffTime.Time)
+		commonEvents.WarningEvent(r.Recorder, far, utils.EventReasonFencingHandOffTimedOut, fmt.Sprintf(utils.EventMessageFencingHandOffTimedOut, managerPod.Name, nodeName, fencingHandOffTimeout))
+	} else {
+		return utils.FencingHandedOff, fmt.Sprintf(utils.EventMessageFencingHandedOff, managerPod.Name), nil
+	}
+
+	if err := r.Delete(ctx, managerPod); err != nil && !apiErrors.IsNotFound(err) {
+		return "", "", fmt.Errorf("failed to delete the manager pod %s: %w", managerPod.Name, err)
+	}
+	now := metav1.Now()
+	far.Status.FencingHandOffTime = &now
+	return utils.FencingHandedOff, fmt.Sprintf(utils.EventMessageFencingHandedOff, managerPod.Name), nil
+}
+
+// checkControlPlaneQuorum checks whether fencing a control-plane node would break the etcd quorum, and returns the waiting reason and
+// the event message if it would. The node itself might still be a healthy etcd member, e.g. when only its kubelet is unhealthy,
+// hence the quorum has to be kept by the other control-plane nodes which are ready and aren't being remediated.
+func (r *FenceAgentsRemediationReconciler) checkControlPlaneQuorum(far *v1alpha1.FenceAgentsRemediation, nodes []corev1.Node,
+	farList []v1alpha1.FenceAgentsRemediation) (utils.ConditionsChangeReason, string) {
+	nodeName := getNodeName(far)
+	unhealthyNodeNames := getUnhealthyNodeNames(farList)
+	isControlPlane, otherNodes, healthyNodes := false, 0, 0
+	for i := range nodes {
+		if !utils.IsControlPlaneNode(&nodes[i]) {
+			continue
+		}
+		if nodes[i].Name == nodeName {
+			isControlPlane = true
+			continue
+		}
+		otherNodes++
+		if utils.IsNodeReady(&nodes[i]) && !unhealthyNodeNames[nodes[i].Name] {
+			healthyNodes++
+		}
+	}
+	if !isControlPlane {
+		return "", ""
+	}
+
+	// the quorum is a majority of all the control-plane nodes, including the fenced one
+	quorum := (otherNodes+1)/2 + 1
+	if healthyNodes >= quorum {
+		return "", ""
+	}
+	if far.Annotations[v1alpha1.AllowQuorumLossAnnotation] == "true" {
+		r.Log.Info("Fencing the control-plane node might break the etcd quorum, but it is allowed by annotation", "Node Name", nodeName,
+			"Healthy Control-Plane Nodes", healthyNodes, "Other Control-Plane Nodes", otherNodes, "Quorum", quorum)
+		commonEvents.WarningEvent(r.Recorder, far, utils.EventReasonQuorumLossAllowed, utils.EventMessageQuorumLossAllowed)
+		return "", ""
+	}
+	r.Log.Info("Fencing the control-plane node would break the etcd quorum, thus the node isn't fenced", "Node Name", nodeName,
+		"Healthy Control-Plane Nodes", healthyNodes, "Other Control-Plane Nodes", otherNodes, "Quorum", quorum)
+	return utils.ControlPlaneQuorumProtected, fmt.Sprintf(utils.EventMessageQuorumProtected, healthyNodes, otherNodes, quorum)
+}
+
+// isFencing checks whether the node of the FAR is being fenced, from its fencing admission until the end of the remediation
+func (r *FenceAgentsRemediationReconciler) isFencing(far *v1alpha1.FenceAgentsRemediation) bool {
+	if !far.DeletionTimestamp.IsZero() || !meta.IsStatusConditionTrue(far.Status.Conditions, commonConditions.ProcessingType) {
+		return false
+	}
+	// a node which was admitted, or whose boot ID was recorded, is fenced by the following reconcile, hence it is counted
+	// before its fence agent is executed, otherwise a waiting node could be admitted in between
+	if waitingCondition := meta.FindStatusCondition(far.Status.Conditions, utils.WaitingType); waitingCondition != nil &&
+		waitingCondition.Status == metav1.ConditionFalse && waitingCondition.Reason == string(utils.FencingAdmitted) {
+		return true
+	}
+	// the executer's routine covers the time between the fence agent execution and the first persisted attempt
+	return far.Status.PreFenceBootID != "" || far.Status.Execution != nil || r.Executor.Exists(far.GetUID()) ||
+		meta.IsStatusConditionTrue(far.Status.Conditions, utils.FenceAgentActionSucceededType)
+}
+
+// getUnhealthyNodeNames returns the names of the nodes which have a FAR that hasn't been completed successfully
+func getUnhealthyNodeNames(farList []v1alpha1.FenceAgentsRemediation) map[string]bool {
+	unhealthyNodes := make(map[string]bool)
+	for i := range farList {
+		far := &farList[i]
+		if far.DeletionTimestamp.IsZero() && !meta.IsStatusConditionTrue(far.Status.Conditions, commonConditions.SucceededType) {
+			unhealthyNodes[getNodeName(far)] = true
+		}
+	}
+	return unhealthyNodes
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/cli"
+)
+
+// fencingChecker checks that the fence agents of a FenceAgentsRemediationTemplate can reach the fencing devices of nodes,
+// by running their status action, which doesn't change the power status of the nodes
+type fencingChecker struct {
+	client.Client
+	Log      logr.Logger
+	Executor *cli.Executer
+}
+
+// checkNodes checks the fencing levels of the template for each node, with at most maxConcurrency nodes which are checked at the same time
+func (c *fencingChecker) checkNodes(ctx context.Context, farTemplate *v1alpha1.FenceAgentsRemediationTemplate, nodeNames []string, maxConcurrency int) []v1alpha1.NodeFencingTestResult {
+	nodesResults := make([][]v1alpha1.NodeFencingTestResult, len(nodeNames))
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	semaphore := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, nodeName := range nodeNames {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, nodeName string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			nodesResults[i] = c.checkNode(ctx, farTemplate, nodeName)
+		}(i, nodeName)
+	}
+	wg.Wait()
+
+	var results []v1alpha1.NodeFencingTestResult
+	for _, nodeResults := range nodesResults {
+		results = append(results, nodeResults...)
+	}
+	return results
+}
+
+// checkNode builds the fence agent status command of each fencing level for the node, the same way a FenceAgentsRemediation
+// of the template would build its fence agent command, and runs it
+func (c *fencingChecker) checkNode(ctx context.Context, farTemplate *v1alpha1.FenceAgentsRemediationTemplate, nodeName string) []v1alpha1.NodeFencingTestResult {
+	far := &v1alpha1.FenceAgentsRemediation{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName, Namespace: farTemplate.Namespace},
+		Spec:       *farTemplate.Spec.Template.Spec.DeepCopy(),
+	}
+	// the parameters are built by the FenceAgentsRemediation reconciler functions, hence the check runs the exact same command
+	farReconciler := &FenceAgentsRemediationReconciler{Client: c.Client, Log: c.Log.WithValues("Node Name", nodeName)}
+
+	var results []v1alpha1.NodeFencingTestResult
+	for i, fencingLevel := range getFencingLevels(far) {
+		result := v1alpha1.NodeFencingTestResult{NodeName: v1alpha1.NodeName(nodeName), Level: i + 1, Agent: fencingLevel.Agent}
+		faParams, hasSecretParams, _, err := farReconciler.buildFenceAgentParams(ctx, far, fencingLevel)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		level, err := farReconciler.buildCLIFencingLevel(far, fencingLevel, faParams, hasSecretParams, parameterStatusActionValue)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		var latency time.Duration
+		var powerStatuses, errs []string
+		for device, command := range level.Commands {
+			powerStatus, commandLatency, err := c.Executor.CheckPowerStatus(ctx, command, level.ParametersOverStdin, level.Timeout)
+			latency += commandLatency
+			if err != nil {
+				if len(level.Commands) > 1 {
+					err = fmt.Errorf("device %d: %w", device+1, err)
+				}
+				errs = append(errs, err.Error())
+				continue
+			}
+			if !slices.Contains(powerStatuses, powerStatus) {
+				powerStatuses = append(powerStatuses, powerStatus)
+			}
+		}
+		result.Reachable = len(errs) == 0
+		result.PowerStatus = strings.Join(powerStatuses, ",")
+		result.Latency = metav1.Duration{Duration: latency.Round(time.Millisecond)}
+		result.Error = strings.Join(errs, "; ")
+		c.Log.Info("Fencing level was checked", "Node Name", nodeName, "Level", result.Level, "Fence Agent", result.Agent,
+			"Reachable", result.Reachable, "Power Status", result.PowerStatus, "Latency", result.Latency.Duration)
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+const (
+	// machineAnnotation is the annotation of the node which refers to its Machine, in the namespace/name form
+	machineAnnotation = "machine.openshift.io/machine"
+	// bareMetalHostAnnotation is the annotation of the Machine which refers to its BareMetalHost, in the namespace/name form
+	bareMetalHostAnnotation = "metal3.io/BareMetalHost"
+
+	// bmcAgentIPMI and bmcAgentRedfish are the fence agents which manage the BMCs of BareMetalHosts
+	bmcAgentIPMI    = "fence_ipmilan"
+	bmcAgentRedfish = "fence_redfish"
+
+	// cloudAgentAWS, cloudAgentAzure and cloudAgentGCE are the fence agents which manage the instances of the cloud providers
+	cloudAgentAWS   = "fence_aws"
+	cloudAgentAzure = "fence_azure_arm"
+	cloudAgentGCE   = "fence_gce"
+)
+
+var (
+	// the Machine and BareMetalHost types aren't dependencies of the operator, hence they are read as unstructured objects,
+	// which are read directly from the API server rather than from the cache
+	machineGVK       = schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "Machine"}
+	bareMetalHostGVK = schema.GroupVersionKind{Group: "metal3.io", Version: "v1alpha1", Kind: "BareMetalHost"}
+	secretGVK        = corev1.SchemeGroupVersion.WithKind("Secret")
+	configMapGVK     = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+)
+
+// collectSourceParams derives the node parameters of the fencing level from its parameters source, and returns whether they include
+// Secret params. It also returns whether the parameters might be derived by retrying, e.g. once the BareMetalHost of a new node is created.
+func (r *FenceAgentsRemediationReconciler) collectSourceParams(ctx context.Context, fencingLevel v1alpha1.FencingLevel, node *corev1.Node) (map[v1alpha1.ParameterName]string, bool, bool, error) {
+	switch fencingLevel.ParametersSource {
+	case "":
+		return nil, false, false, nil
+	case v1alpha1.BareMetalHostParametersSource:
+		return r.collectBareMetalHostParams(ctx, fencingLevel.Agent, node)
+	case v1alpha1.ProviderIDParametersSource:
+		return r.collectProviderIDParams(fencingLevel.Agent, node)
+	default:
+		// this should never happen since we enforce valid values with kubebuilder
+		return nil, false, false, fmt.Errorf("unsupported parameters source %s", fencingLevel.ParametersSource)
+	}
+}
+
+// collectBareMetalHostParams follows the node to its Machine, and the Machine to its BareMetalHost, and derives the fence agent parameters
+// from the BMC address of the BareMetalHost and from its BMC credentials Secret
+func (r *FenceAgentsRemediationReconciler) collectBareMetalHostParams(ctx context.Context, agent string, node *corev1.Node) (map[v1alpha1.ParameterName]string, bool, bool, error) {
+	machine, err := r.getAnnotatedObject(ctx, node, machineAnnotation, machineGVK)
+	if err != nil {
+		return nil, false, true, err
+	}
+	bmh, err := r.getAnnotatedObject(ctx, machine, bareMetalHostAnnotation, bareMetalHostGVK)
+	if err != nil {
+		return nil, false, true, err
+	}
+
+	address, _, _ := unstructured.NestedString(bmh.Object, "spec", "bmc", "address")
+	disableCertificateVerification, _, _ := unstructured.NestedBool(bmh.Object, "spec", "bmc", "disableCertificateVerification")
+	bmcAgent, params, err := getBMCParams(address, disableCertificateVerification)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("invalid BMC address of BareMetalHost %s/%s: %w", bmh.GetNamespace(), bmh.GetName(), err)
+	}
+	if bmcAgent != agent {
+		return nil, false, false, fmt.Errorf("the BMC address %s of BareMetalHost %s/%s is managed by fence agent %s rather than by %s",
+			address, bmh.GetNamespace(), bmh.GetName(), bmcAgent, agent)
+	}
+
+	credentialsName, _, _ := unstructured.NestedString(bmh.Object, "spec", "bmc", "credentialsName")
+	if credentialsName == "" {
+		r.Log.Info("BareMetalHost has no BMC credentials Secret", "Node Name", node.Name, "BareMetalHost", bmh.GetName())
+		return params, false, false, nil
+	}
+	credentials := &unstructured.Unstructured{}
+	credentials.SetGroupVersionKind(secretGVK)
+	if err := r.Get(ctx, client.ObjectKey{Name: credentialsName, Namespace: bmh.GetNamespace()}, credentials); err != nil {
+		return nil, false, true, fmt.Errorf(errorFailGettingSecret, credentialsName, bmh.GetNamespace(), err)
+	}
+	for secretKey, paramName := range map[string]v1alpha1.ParameterName{"username": "--username", "password": "--password"} {
+		encodedVal, _, _ := unstructured.NestedString(credentials.Object, "data", secretKey)
+		secretVal, err := base64.StdEncoding.DecodeString(encodedVal)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("invalid %s of BMC credentials Secret %s/%s: %w", secretKey, bmh.GetNamespace(), credentialsName, err)
+		}
+		params[paramName] = string(secretVal)
+	}
+	r.Log.Info("Fence agent parameters were derived from the BareMetalHost", "Node Name", node.Name, "BareMetalHost", bmh.GetName(),
+		"Parameters", len(params))
+	return params, true, false, nil
+}
+
+// collectProviderIDParams derives the fence agent parameters of the node's cloud instance from the provider ID of the node
+func (r *FenceAgentsRemediationReconciler) collectProviderIDParams(agent string, node *corev1.Node) (map[v1alpha1.ParameterName]string, bool, bool, error) {
+	if node.Spec.ProviderID == "" {
+		// the provider ID of a new node is set by the cloud controller manager once the node is initialized
+		return nil, false, true, fmt.Errorf("node %s has no provider ID", node.Name)
+	}
+	cloudAgent, params, err := getProviderIDParams(node.Spec.ProviderID)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("invalid provider ID of node %s: %w", node.Name, err)
+	}
+	if cloudAgent != agent {
+		return nil, false, false, fmt.Errorf("the provider ID %s of node %s is managed by fence agent %s rather than by %s",
+			node.Spec.ProviderID, node.Name, cloudAgent, agent)
+	}
+	r.Log.Info("Fence agent parameters were derived from the provider ID", "Node Name", node.Name, "Provider ID", node.Spec.ProviderID)
+	return params, false, false, nil
+}
+
+// getAnnotatedObject gets the object which is referred by the annotation of the given object, in the namespace/name form
+func (r *FenceAgentsRemediationReconciler) getAnnotatedObject(ctx context.Context, obj client.Object, annotation string, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	ref, exists := obj.GetAnnotations()[annotation]
+	if !exists {
+		return nil, fmt.Errorf("%s has no %s annotation", obj.GetName(), annotation)
+	}
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		return nil, fmt.Errorf("annotation %s of %s isn't in the namespace/name form: %s", annotation, obj.GetName(), ref)
+	}
+	annotated := &unstructured.Unstructured{}
+	annotated.SetGroupVersionKind(gvk)
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, annotated); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, ref, err)
+	}
+	return annotated, nil
+}
+
+// getBMCParams returns the fence agent which manages the BMC with the given Metal3 BMC address, and its parameters, e.g.
+// ipmi://192.168.111.1:6230 is managed by fence_ipmilan, and redfish://192.168.111.1:8000/redfish/v1/Systems/1 is managed by fence_redfish
+func getBMCParams(address string, disableCertificateVerification bool) (string, map[v1alpha1.ParameterName]string, error) {
+	if address == "" {
+		return "", nil, fmt.Errorf("the BMC address is empty")
+	}
+	if !strings.Contains(address, "://") {
+		// an address without a scheme is an IPMI address
+		address = "ipmi://" + address
+	}
+	bmcURL, err := url.Parse(address)
+	if err != nil {
+		return "", nil, err
+	}
+
+	params := map[v1alpha1.ParameterName]string{"--ip": bmcURL.Hostname()}
+	if port := bmcURL.Port(); port != "" {
+		params["--ipport"] = port
+	}
+	protocol, transport, _ := strings.Cut(bmcURL.Scheme, "+")
+	switch {
+	case protocol == "ipmi":
+		params["--lanplus"] = ""
+		return bmcAgentIPMI, params, nil
+	// the virtual media schemes of the other vendors, e.g. ilo4-virtualmedia, aren't managed over Redfish
+	case protocol == "redfish" || strings.HasSuffix(protocol, "-redfish") || protocol == "redfish-virtualmedia" || protocol == "idrac-virtualmedia":
+		if bmcURL.Path != "" && bmcURL.Path != "/" {
+			params["--systems-uri"] = bmcURL.Path
+		}
+		if transport != "http" {
+			if disableCertificateVerification {
+				params["--ssl-insecure"] = ""
+			} else {
+				params["--ssl-secure"] = ""
+			}
+		}
+		return bmcAgentRedfish, params, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported BMC protocol %s", bmcURL.Scheme)
+	}
+}
+
+// getProviderIDParams returns the fence agent which manages the cloud instance with the given provider ID, and its parameters, e.g.
+// aws:///us-east-1a/i-0123456789abcdef0 is managed by fence_aws with --plug=i-0123456789abcdef0 and --region=us-east-1
+func getProviderIDParams(providerID string) (string, map[v1alpha1.ParameterName]string, error) {
+	provider, path, found := strings.Cut(providerID, "://")
+	if !found {
+		return "", nil, fmt.Errorf("the provider ID %s has no provider", providerID)
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch provider {
+	case "aws":
+		// aws:///<zone>/<instance ID>, or aws:///<instance ID> when the zone is unknown
+		instanceID := segments[len(segments)-1]
+		if !strings.HasPrefix(instanceID, "i-") {
+			return "", nil, fmt.Errorf("unexpected AWS instance ID %s", instanceID)
+		}
+		params := map[v1alpha1.ParameterName]string{"--plug": instanceID}
+		if len(segments) > 1 {
+			params["--region"] = getAWSRegion(segments[len(segments)-2])
+		}
+		return cloudAgentAWS, params, nil
+	case "azure":
+		// azure:///subscriptions/<subscription ID>/resourceGroups/<resource group>/providers/Microsoft.Compute/virtualMachines/<VM name>
+		if len(segments) != 8 || !strings.EqualFold(segments[0], "subscriptions") || !strings.EqualFold(segments[2], "resourceGroups") ||
+			!strings.EqualFold(segments[6], "virtualMachines") {
+			return "", nil, fmt.Errorf("the provider ID %s isn't of an Azure virtual machine", providerID)
+		}
+		return cloudAgentAzure, map[v1alpha1.ParameterName]string{
+			"--plug":           segments[7],
+			"--resourceGroup":  segments[3],
+			"--subscriptionId": segments[1],
+		}, nil
+	case "gce":
+		// gce://<project>/<zone>/<instance name>
+		if len(segments) != 3 || segments[0] == "" {
+			return "", nil, fmt.Errorf("the provider ID %s isn't of a GCE instance", providerID)
+		}
+		return cloudAgentGCE, map[v1alpha1.ParameterName]string{
+			"--plug":    segments[2],
+			"--zone":    segments[1],
+			"--project": segments[0],
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported cloud provider %s", provider)
+	}
+}
+
+// getAWSRegion returns the region of the AWS availability zone, i.e. its segments up to the first one which starts with a digit, without
+// the zone letters, e.g. us-east-1 of us-east-1a, us-gov-west-1 of us-gov-west-1a, and us-west-2 of the local zone us-west-2-lax-1a
+func getAWSRegion(zone string) string {
+	parts := strings.Split(zone, "-")
+	for i, part := range parts {
+		if part != "" && part[0] >= '0' && part[0] <= '9' {
+			parts[i] = strings.TrimRight(part, "abcdefghijklmnopqrstuvwxyz")
+			return strings.Join(parts[:i+1], "-")
+		}
+	}
+	return zone
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+var _ = Describe("Parameters Source", func() {
+	DescribeTable("BMC address params",
+		func(address string, disableCertificateVerification bool, expectedAgent string, expectedParams map[v1alpha1.ParameterName]string) {
+			agent, params, err := getBMCParams(address, disableCertificateVerification)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(agent).To(Equal(expectedAgent))
+			Expect(params).To(Equal(expectedParams))
+		},
+		Entry("IPMI address", "ipmi://192.168.111.1:6233", false, "fence_ipmilan",
+			map[v1alpha1.ParameterName]string{"--ip": "192.168.111.1", "--ipport": "6233", "--lanplus": ""}),
+		Entry("address without a scheme", "192.168.111.1", false, "fence_ipmilan",
+			map[v1alpha1.ParameterName]string{"--ip": "192.168.111.1", "--lanplus": ""}),
+		Entry("Redfish address", "redfish://bmc.example.com:8000/redfish/v1/Systems/1", false, "fence_redfish",
+			map[v1alpha1.ParameterName]string{"--ip": "bmc.example.com", "--ipport": "8000", "--systems-uri": "/redfish/v1/Systems/1", "--ssl-secure": ""}),
+		Entry("Redfish virtual media address without certificate verification", "redfish-virtualmedia://bmc.example.com/redfish/v1/Systems/1", true,
+			"fence_redfish", map[v1alpha1.ParameterName]string{"--ip": "bmc.example.com", "--systems-uri": "/redfish/v1/Systems/1", "--ssl-insecure": ""}),
+		Entry("vendor Redfish address over HTTP", "idrac-redfish+http://192.168.111.1/redfish/v1/Systems/System.Embedded.1", false, "fence_redfish",
+			map[v1alpha1.ParameterName]string{"--ip": "192.168.111.1", "--systems-uri": "/redfish/v1/Systems/System.Embedded.1"}),
+	)
+
+	DescribeTable("invalid BMC address",
+		func(address, expectedErr string) {
+			_, _, err := getBMCParams(address, false)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("empty address", "", "the BMC address is empty"),
+		Entry("unsupported protocol", "idrac://192.168.111.1", "unsupported BMC protocol idrac"),
+		Entry("iLO 4 virtual media", "ilo4-virtualmedia://192.168.111.1", "unsupported BMC protocol ilo4-virtualmedia"),
+	)
+
+	DescribeTable("provider ID params",
+		func(providerID string, expectedAgent string, expectedParams map[v1alpha1.ParameterName]string) {
+			agent, params, err := getProviderIDParams(providerID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(agent).To(Equal(expectedAgent))
+			Expect(params).To(Equal(expectedParams))
+		},
+		Entry("AWS instance", "aws:///us-east-1a/i-0123456789abcdef0", "fence_aws",
+			map[v1alpha1.ParameterName]string{"--plug": "i-0123456789abcdef0", "--region": "us-east-1"}),
+		Entry("AWS instance in a local zone", "aws:///us-west-2-lax-1a/i-0123456789abcdef0", "fence_aws",
+			map[v1alpha1.ParameterName]string{"--plug": "i-0123456789abcdef0", "--region": "us-west-2"}),
+		Entry("AWS GovCloud instance", "aws:///us-gov-west-1a/i-0123456789abcdef0", "fence_aws",
+			map[v1alpha1.ParameterName]string{"--plug": "i-0123456789abcdef0", "--region": "us-gov-west-1"}),
+		Entry("AWS instance in a wavelength zone", "aws:///us-east-1-wl1-bos-wlz-1/i-0123456789abcdef0", "fence_aws",
+			map[v1alpha1.ParameterName]string{"--plug": "i-0123456789abcdef0", "--region": "us-east-1"}),
+		Entry("AWS instance without a zone", "aws:///i-0123456789abcdef0", "fence_aws",
+			map[v1alpha1.ParameterName]string{"--plug": "i-0123456789abcdef0"}),
+		Entry("Azure virtual machine",
+			"azure:///subscriptions/1234-5678/resourceGroups/cluster-rg/providers/Microsoft.Compute/virtualMachines/cluster-worker-0", "fence_azure_arm",
+			map[v1alpha1.ParameterName]string{"--plug": "cluster-worker-0", "--resourceGroup": "cluster-rg", "--subscriptionId": "1234-5678"}),
+		Entry("GCE instance", "gce://my-project/us-central1-a/cluster-worker-0", "fence_gce",
+			map[v1alpha1.ParameterName]string{"--plug": "cluster-worker-0", "--zone": "us-central1-a", "--project": "my-project"}),
+	)
+
+	DescribeTable("invalid provider ID",
+		func(providerID, expectedErr string) {
+			_, _, err := getProviderIDParams(providerID)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("provider ID without a provider", "i-0123456789abcdef0", "has no provider"),
+		Entry("unsupported provider", "openstack:///4b1d4a3c", "unsupported cloud provider openstack"),
+		Entry("Azure scale set instance",
+			"azure:///subscriptions/1234-5678/resourceGroups/cluster-rg/providers/Microsoft.Compute/virtualMachineScaleSets/workers/virtualMachines/0",
+			"isn't of an Azure virtual machine"),
+		Entry("GCE provider ID without a zone", "gce://my-project/cluster-worker-0", "isn't of a GCE instance"),
+	)
+})
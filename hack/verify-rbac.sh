@@ -0,0 +1,34 @@
+#!/bin/bash
+# Verifies that the rendered manifests grant the manager the permissions which it needs outside of the operator's namespace,
+# i.e. to get the BMC credentials Secrets of the BareMetalHosts, both when it is deployed by kustomize and when it is installed by OLM.
+# Usage: verify-rbac.sh <kustomize> <yq>
+
+set -e
+
+KUSTOMIZE=${1:-kustomize}
+YQ=${2:-yq}
+CSV=bundle/manifests/fence-agents-remediation.clusterserviceversion.yaml
+SECRETS_GET='.rules[] | select(.apiGroups[] == "" and .resources[] == "secrets" and .verbs[] == "get")'
+
+if ! manifests=$(${KUSTOMIZE} build config/default); then
+    echo "The manifests of config/default can't be rendered"
+    exit 1
+fi
+
+# the ClusterRoles which are bound to the manager's service account
+roles=$(echo "${manifests}" | ${YQ} 'select(.kind == "ClusterRoleBinding" and .subjects[].name == "fence-agents-remediation-controller-manager") | .roleRef.name' | grep -v -- '---')
+allowed=false
+for role in ${roles}; do
+    if [[ -n "$(echo "${manifests}" | ${YQ} "select(.kind == \"ClusterRole\" and .metadata.name == \"${role}\") | ${SECRETS_GET}")" ]]; then
+        allowed=true
+    fi
+done
+if [[ "${allowed}" != "true" ]]; then
+    echo "The rendered manifests don't allow the manager to get the BMC credentials Secrets"
+    exit 1
+fi
+
+if [[ -z "$(${YQ} ".spec.install.spec.clusterPermissions[] | ${SECRETS_GET}" ${CSV})" ]]; then
+    echo "The bundle CSV doesn't allow the manager to get the BMC credentials Secrets"
+    exit 1
+fi
@@ -23,10 +23,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"go.uber.org/zap/zapcore"
 
 	pkgruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
@@ -73,6 +75,9 @@ func main() {
 		probeAddr            string
 		enableHTTP2          bool
 		webhookOpts          webhook.Options
+		maxConcurrentFencing string
+		stormThreshold       string
+		maxDeviceSessions    int
 	)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -80,6 +85,14 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false, "If HTTP/2 should be enabled for the metrics and webhook servers.")
+	flag.StringVar(&maxConcurrentFencing, "max-concurrent-fencing", "",
+		"The maximal number (e.g. 3) or percentage of the cluster nodes (e.g. 20%) of nodes which are fenced at the same time. "+
+			"The number of nodes isn't limited when it is empty.")
+	flag.StringVar(&stormThreshold, "remediation-storm-threshold", "",
+		"The number (e.g. 5) or percentage of the cluster nodes (e.g. 50%) of unhealthy nodes above which new nodes aren't fenced. "+
+			"The remediation storm protection is disabled when it is empty.")
+	flag.IntVar(&maxDeviceSessions, "max-fencing-device-sessions", cli.DefaultMaxDeviceSessions,
+		"The maximal number of fence agent commands which run against the same fencing device, identified by its address and port, at the same time.")
 
 	opts := zap.Options{
 		Development: true,
@@ -92,6 +105,17 @@ func main() {
 
 	printVersion()
 
+	maxConcurrentFencingLimit, err := parseIntOrPercent(maxConcurrentFencing)
+	if err != nil {
+		setupLog.Error(err, "invalid max-concurrent-fencing flag")
+		os.Exit(1)
+	}
+	stormThresholdLimit, err := parseIntOrPercent(stormThreshold)
+	if err != nil {
+		setupLog.Error(err, "invalid remediation-storm-threshold flag")
+		os.Exit(1)
+	}
+
 	configureWebhookOpts(&webhookOpts, enableHTTP2)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -100,6 +124,8 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "cb305759.medik8s.io",
+		// a manager which hands off a remediation deletes its own pod, so a standby replica should take over without waiting for the lease to expire
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -116,20 +142,61 @@ func main() {
 	}
 	fenceagentsremediationv1alpha1.InitOutOfServiceTaintSupportedFlag(isOutOfServiceTaintSupported)
 
-	executer, err := cli.NewExecuter(mgr.GetClient(), mgr.GetEventRecorderFor(operatorName+"-executer"))
+	executer, err := cli.NewExecuter(mgr.GetClient(), mgr.GetEventRecorderFor(operatorName+"-executer"), maxDeviceSessions)
 	if err != nil {
 		setupLog.Error(err, "unable to create executer")
 		os.Exit(1)
 	}
 
+	// the pod name is the hostname of its containers
+	podName, err := os.Hostname()
+	if err != nil {
+		setupLog.Error(err, "unable to get the manager pod name")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.FenceAgentsRemediationReconciler{
+		Client:                     mgr.GetClient(),
+		Log:                        ctrl.Log.WithName("controllers").WithName(operatorName),
+		Scheme:                     mgr.GetScheme(),
+		Recorder:                   mgr.GetEventRecorderFor(operatorName),
+		Executor:                   executer,
+		MaxConcurrentFencing:       maxConcurrentFencingLimit,
+		StormThreshold:             stormThresholdLimit,
+		OutOfServiceTaintSupported: isOutOfServiceTaintSupported,
+		PodName:                    podName,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", operatorName)
+		os.Exit(1)
+	}
+
+	if err = (&controllers.FenceAgentsRemediationTestReconciler{
 		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName(operatorName),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor(operatorName),
+		Log:      ctrl.Log.WithName("controllers").WithName("fence-agents-remediation-test"),
+		Recorder: mgr.GetEventRecorderFor(operatorName + "-test"),
 		Executor: executer,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", operatorName)
+		setupLog.Error(err, "unable to create controller", "controller", "FenceAgentsRemediationTest")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.FenceAgentsRemediationTemplateReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("fence-agents-remediation-template"),
+		Recorder: mgr.GetEventRecorderFor(operatorName + "-template"),
+		Executor: executer,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FenceAgentsRemediationTemplate")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(&controllers.FenceAgentCatalog{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("fence-agent-catalog"),
+		ListAgents:  validation.ListAgents,
+		GetMetadata: validation.GetAgentMetadata,
+	}); err != nil {
+		setupLog.Error(err, "unable to create fence agent catalog")
 		os.Exit(1)
 	}
 
@@ -198,3 +265,22 @@ func configureWebhookOpts(webhookOpts *webhook.Options, enableHTTP2 bool) {
 	}
 
 }
+
+// parseIntOrPercent parses a flag value which is either a non-negative number or a percentage, or returns nil when the value is empty
+func parseIntOrPercent(value string) (*intstr.IntOrString, error) {
+	if value == "" {
+		return nil, nil
+	}
+	limit := intstr.Parse(value)
+	if limit.Type == intstr.String && !strings.HasSuffix(limit.StrVal, "%") {
+		return nil, fmt.Errorf("%q is neither a number nor a percentage", value)
+	}
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(&limit, 100, false)
+	if err != nil {
+		return nil, err
+	}
+	if scaled < 0 {
+		return nil, fmt.Errorf("%q is negative", value)
+	}
+	return &limit, nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+const (
+	// maxAttempts is the number of fence agent attempts which are kept in the FAR status
+	maxAttempts = 10
+	// maxOutputExcerptLength is the maximal length of the stdout and stderr excerpts of a fence agent attempt
+	maxOutputExcerptLength = 512
+	// redactedValue replaces the sensitive parameter values in the fence agent output
+	redactedValue = "*****"
+	// truncatedPrefix prefixes an output excerpt which was truncated
+	truncatedPrefix = "..."
+)
+
+// sensitiveParamRegex matches the names of parameters which hold credentials, e.g. --password, --pass, --secret-key or --community
+var sensitiveParamRegex = regexp.MustCompile(`(?i)(pass|secret|token|key|community)`)
+
+// newAttempt builds the record of a completed fence agent command attempt
+func newAttempt(ctxWithTimeout context.Context, level, attemptNumber int, command []string, start, end time.Time, queueWait time.Duration, stdout, stderr string,
+	err error) v1alpha1.FenceAgentAttempt {
+	attempt := v1alpha1.FenceAgentAttempt{
+		Agent:     command[0],
+		Level:     level,
+		Attempt:   attemptNumber,
+		StartTime: metav1.NewTime(start),
+		EndTime:   metav1.NewTime(end),
+		TimedOut:  errors.Is(ctxWithTimeout.Err(), context.DeadlineExceeded),
+		Stdout:    outputExcerpt(redactOutput(stdout, command)),
+		Stderr:    outputExcerpt(redactOutput(stderr, command)),
+	}
+	if queueWait = queueWait.Round(time.Millisecond); queueWait > 0 {
+		attempt.QueueWait = &metav1.Duration{Duration: queueWait}
+	}
+	var exitErr *exec.ExitError
+	if err == nil {
+		exitCode := int32(0)
+		attempt.ExitCode = &exitCode
+	} else if errors.As(err, &exitErr) && exitErr.Exited() {
+		exitCode := int32(exitErr.ExitCode())
+		attempt.ExitCode = &exitCode
+	}
+	return attempt
+}
+
+// redactOutput replaces the values of the command's sensitive parameters in the fence agent output
+func redactOutput(output string, command []string) string {
+	for _, param := range command[1:] {
+		name, value, found := strings.Cut(param, "=")
+		if !found || value == "" || !sensitiveParamRegex.MatchString(name) {
+			continue
+		}
+		output = strings.ReplaceAll(output, value, redactedValue)
+	}
+	return output
+}
+
+// outputExcerpt returns the end of the fence agent output, where the agents print their errors, limited to maxOutputExcerptLength
+func outputExcerpt(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxOutputExcerptLength {
+		return output
+	}
+	// the cut might split a multi-byte character
+	return truncatedPrefix + strings.ToValidUTF8(output[len(output)-maxOutputExcerptLength+len(truncatedPrefix):], "")
+}
+
+// appendAttempt appends the attempt to the attempts, and drops the oldest attempts beyond maxAttempts
+func appendAttempt(attempts []v1alpha1.FenceAgentAttempt, attempt v1alpha1.FenceAgentAttempt) []v1alpha1.FenceAgentAttempt {
+	attempts = append(attempts, attempt)
+	if len(attempts) > maxAttempts {
+		attempts = attempts[len(attempts)-maxAttempts:]
+	}
+	return attempts
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+func Test_redactOutput(t *testing.T) {
+	command := []string{"fence_ipmilan", "--ip=192.168.111.1", "--username=admin", "--password=s3cret", "--pass2=abc2", "--lanplus"}
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "noSensitiveValue", output: "Failed: Unable to connect to 192.168.111.1", want: "Failed: Unable to connect to 192.168.111.1"},
+		{name: "password", output: "Executing: ipmitool -P s3cret -U admin", want: "Executing: ipmitool -P ***** -U admin"},
+		{name: "passPrefix", output: "using abc2 and s3cret", want: "using ***** and *****"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactOutput(tt.output, command); got != tt.want {
+				t.Errorf("redactOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_outputExcerpt(t *testing.T) {
+	longOutput := strings.Repeat("a", maxOutputExcerptLength) + "Failed: Unable to connect"
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "empty", output: "", want: ""},
+		{name: "short", output: "Status: ON\n", want: "Status: ON"},
+		{name: "long", output: longOutput, want: truncatedPrefix + longOutput[len(longOutput)-maxOutputExcerptLength+len(truncatedPrefix):]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := outputExcerpt(tt.output)
+			if got != tt.want || len(got) > maxOutputExcerptLength {
+				t.Errorf("outputExcerpt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_appendAttempt(t *testing.T) {
+	var attempts []v1alpha1.FenceAgentAttempt
+	for i := 1; i <= maxAttempts+2; i++ {
+		attempts = appendAttempt(attempts, v1alpha1.FenceAgentAttempt{Agent: fmt.Sprintf("fence_%d", i)})
+	}
+	if len(attempts) != maxAttempts {
+		t.Fatalf("appendAttempt() kept %d attempts, want %d", len(attempts), maxAttempts)
+	}
+	// the oldest attempts are dropped
+	if first, last := attempts[0].Agent, attempts[maxAttempts-1].Agent; first != "fence_3" || last != fmt.Sprintf("fence_%d", maxAttempts+2) {
+		t.Errorf("appendAttempt() kept attempts %s to %s, want fence_3 to fence_%d", first, last, maxAttempts+2)
+	}
+}
+
+func Test_CheckPowerStatusRedactsStderr(t *testing.T) {
+	command := []string{"fence_ipmilan", "--ip=192.168.111.1", "--password=s3cret", "--action=status"}
+	longStderr := strings.Repeat("a", maxOutputExcerptLength) + "Failed: Unable to connect with s3cret"
+	e := NewFakeExecuter(nil, func(context.Context, []string, bool) (string, string, error) {
+		return "", longStderr, errors.New("exit status 1")
+	}, nil)
+
+	_, _, err := e.CheckPowerStatus(context.Background(), command, false, time.Second)
+	if err == nil {
+		t.Fatal("CheckPowerStatus() didn't return an error")
+	}
+	if strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), "Failed: Unable to connect with "+redactedValue) {
+		t.Errorf("CheckPowerStatus() error = %q, want a redacted stderr", err.Error())
+	}
+	if len(err.Error()) > len("exit status 1: ")+maxOutputExcerptLength {
+		t.Errorf("CheckPowerStatus() error length = %d, want an excerpt of the stderr", len(err.Error()))
+	}
+}
+
+func Test_runWithRetryRecordsAttemptNumbers(t *testing.T) {
+	far := &v1alpha1.FenceAgentsRemediation{ObjectMeta: metav1.ObjectMeta{Name: "worker-0", UID: "far-uid"}}
+	runs := 0
+	e := NewFakeExecuter(&statusClient{far: far}, func(context.Context, []string, bool) (string, string, error) {
+		// the first attempt fails, and the second one succeeds
+		if runs++; runs == 1 {
+			return "", "Failed: Unable to connect", errors.New("exit status 1")
+		}
+		return "Success: Rebooted", "", nil
+	}, nil)
+	command := []string{"fence_ipmilan", "--ip=192.168.111.1", "--action=reboot"}
+	level := FencingLevel{Number: 2, Commands: [][]string{command}, RetryCount: 3, RetryInterval: time.Millisecond, Timeout: time.Second}
+
+	if retryErr, faErr := e.runWithRetry(context.Background(), far.UID, level, command); retryErr != nil || faErr != nil {
+		t.Fatalf("runWithRetry() = %v, %v, want no errors", retryErr, faErr)
+	}
+	if len(far.Status.Attempts) != 2 {
+		t.Fatalf("runWithRetry() recorded %d attempts, want 2", len(far.Status.Attempts))
+	}
+	for i, attempt := range far.Status.Attempts {
+		if attempt.Level != level.Number || attempt.Attempt != i+1 {
+			t.Errorf("attempt %d was recorded as level %d, attempt %d, want level %d, attempt %d", i+1, attempt.Level, attempt.Attempt, level.Number, i+1)
+		}
+	}
+	if execution := far.Status.Execution; execution == nil || execution.Attempt != 2 {
+		t.Errorf("runWithRetry() recorded the execution %+v, want attempt 2", execution)
+	}
+}
+
+// statusClient is a client.Client which holds a single FAR, and supports only listing it and updating its status
+type statusClient struct {
+	client.Client
+	far *v1alpha1.FenceAgentsRemediation
+}
+
+func (c *statusClient) List(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+	list.(*v1alpha1.FenceAgentsRemediationList).Items = []v1alpha1.FenceAgentsRemediation{*c.far.DeepCopy()}
+	return nil
+}
+
+func (c *statusClient) Status() client.SubResourceWriter {
+	return &statusWriter{far: c.far}
+}
+
+// statusWriter updates the status of the FAR of the statusClient
+type statusWriter struct {
+	client.SubResourceWriter
+	far *v1alpha1.FenceAgentsRemediation
+}
+
+func (w *statusWriter) Update(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	obj.(*v1alpha1.FenceAgentsRemediation).DeepCopyInto(w.far)
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -13,7 +15,9 @@ import (
 	commonEvents "github.com/medik8s/common/pkg/events"
 
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -21,16 +25,35 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/metrics"
 	"github.com/medik8s/fence-agents-remediation/pkg/utils"
 )
 
 const (
-	FenceAgentContextCanceledMessage = "fence agent context canceled. Nothing to do"
-	FenceAgentContextTimedOutMessage = "fence agent context timed out"
-	FenceAgentRetryErrorMessage      = "fence agent retry error"
-	FenceAgentFailedCommandMessage   = "command failed"
+	FenceAgentContextCanceledMessage    = "fence agent context canceled. Nothing to do"
+	FenceAgentContextTimedOutMessage    = "fence agent context timed out"
+	FenceAgentRetryErrorMessage         = "fence agent retry error"
+	FenceAgentFailedCommandMessage      = "command failed"
+	FenceAgentUnexpectedStatusMessage   = "unexpected power status"
+	FenceAgentVerificationFailedMessage = "fence agent power status verification failed"
+	FenceAgentFallbackMessage           = "fencing level failed, falling back to the next fencing level"
+	FenceAgentDeviceFailedMessage       = "fencing device failed, thus the whole fencing level failed"
+	FenceAgentPartialFencingMessage     = "fencing level failed after some of its devices were powered off, and they remain powered off"
+
+	// PowerStatusOn and PowerStatusOff are the power states reported by the fence agent status action
+	PowerStatusOn  = "ON"
+	PowerStatusOff = "OFF"
+
+	// offActionValue is the fence agent action which powers off a fencing device
+	offActionValue = "off"
+
+	// maxCheckDeviceWait is the maximum time a power status check waits for a busy fencing device
+	maxCheckDeviceWait = 2 * time.Minute
 )
 
+// powerStatusRegex matches the power status printed by the fence agent status action, e.g. "Status: ON"
+var powerStatusRegex = regexp.MustCompile(`Status: (ON|OFF)`)
+
 type routine struct {
 	cancel context.CancelFunc
 }
@@ -38,31 +61,113 @@ type routine struct {
 type Executer struct {
 	client.Client
 	log          logr.Logger
+	identity     string
 	routines     map[types.UID]*routine
 	routinesLock sync.Mutex
 	runner       runnerFunc
 	recorder     record.EventRecorder
+	devices      *deviceLimiter
+}
+
+// FencingLevel holds the fence agent commands of a fencing level, and their retry and timeout settings.
+// A fencing level has a command per fencing device, and it is executed as one unit: all the commands are executed
+// one after another, then all the power on commands, and a failure of any device fails the whole fencing level.
+type FencingLevel struct {
+	// Number is the fencing level number, where level 1 is the fence agent of the spec, and the fallback levels start at level 2
+	Number int
+	// Commands are the fence agent command lines, one per fencing device
+	Commands [][]string
+	// PowerOnCommands are the fence agent command lines which power on the fencing devices after all the Commands have succeeded,
+	// e.g. when a node with redundant power supplies is rebooted by powering off all of its outlets and then powering them on
+	PowerOnCommands [][]string
+	// RetryCount is the number of times the command will be executed
+	RetryCount int
+	// PreviousAttempts is the number of attempts which were already made by a previous manager, e.g. before a manager restart,
+	// and they are deducted from the RetryCount
+	PreviousAttempts int
+	// RetryInterval is the interval between each command execution
+	RetryInterval time.Duration
+	// Timeout is the timeout for each command execution
+	Timeout time.Duration
+	// ParametersOverStdin passes the commands' parameters to the fence agent over stdin rather than on the command line
+	ParametersOverStdin bool
+	// Verification verifies the node's power status after the command has succeeded, if it isn't nil
+	Verification *Verification
+}
+
+// agent returns the fence agent name of the fencing level
+func (l FencingLevel) agent() string {
+	return l.Commands[0][0]
+}
+
+// Verification holds the fence agent status commands which verify the node's power status after a successful fence agent action
+type Verification struct {
+	// Commands are the fence agent command lines with the status action, one per fencing device
+	Commands [][]string
+	// ExpectedStatus is the power status the fence agent should report for every fencing device, e.g. PowerStatusOn
+	ExpectedStatus string
+	// Timeout is the time window in which the expected power status has to be reported
+	Timeout time.Duration
+	// Interval is the interval between each power status check
+	Interval time.Duration
+	// ParametersOverStdin passes the commands' parameters to the fence agent over stdin rather than on the command line
+	ParametersOverStdin bool
 }
 
-// runnerFunc is a function that runs the command and returns the stdout, stderr and error
-// it is configurable in Executer for testing purposes
-type runnerFunc func(ctx context.Context, command []string) (string, string, error)
+// runnerFunc is a function that runs the command, with its parameters either on the command line or over stdin,
+// and returns the stdout, stderr and error. It is configurable in Executer for testing purposes
+type runnerFunc func(ctx context.Context, command []string, parametersOverStdin bool) (string, string, error)
 
-// NewExecuter builds the Executer
-func NewExecuter(client client.Client, newRecorder record.EventRecorder) (*Executer, error) {
+// NewExecuter builds the Executer, which runs up to maxDeviceSessions fence agent commands against the same fencing device at the same time
+func NewExecuter(client client.Client, newRecorder record.EventRecorder, maxDeviceSessions int) (*Executer, error) {
 	logger := ctrl.Log.WithName("executer")
 
+	// the identity is unique per manager process, like the leader election identity, thus a restarted manager container gets a new identity
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hostname for the executer identity: %w", err)
+	}
+
 	return &Executer{
 		Client:   client,
 		log:      logger,
+		identity: hostname + "_" + string(uuid.NewUUID()),
 		routines: make(map[types.UID]*routine),
 		runner:   run,
 		recorder: newRecorder,
+		devices:  newDeviceLimiter(maxDeviceSessions),
 	}, nil
 }
 
-// AsyncExecute runs the command in a goroutine mapped to the UID
-func (e *Executer) AsyncExecute(ctx context.Context, uid types.UID, command []string, retryCount int, retryInterval, timeout time.Duration) {
+// Identity returns the identity of the manager which runs the Executer, which is recorded as the owner of the fence agent executions
+func (e *Executer) Identity() string {
+	return e.identity
+}
+
+// AsyncExecute runs the fencing levels one after another, until one of them succeeds, in a goroutine mapped to the UID
+func (e *Executer) AsyncExecute(ctx context.Context, uid types.UID, levels []FencingLevel) {
+	e.startRoutine(ctx, uid, func(ctx context.Context) {
+		e.fenceAgentRoutine(ctx, uid, levels)
+	})
+}
+
+// AsyncPowerOn runs the fencing level command which powers on a powered off node in a goroutine mapped to the UID
+func (e *Executer) AsyncPowerOn(ctx context.Context, uid types.UID, level FencingLevel) {
+	e.startRoutine(ctx, uid, func(ctx context.Context) {
+		e.powerOnRoutine(ctx, uid, level)
+	})
+}
+
+// AsyncVerify runs the power status verification of a successful fence agent action in a goroutine mapped to the UID,
+// e.g. when the verification of a previous manager was interrupted
+func (e *Executer) AsyncVerify(ctx context.Context, uid types.UID, verification *Verification) {
+	e.startRoutine(ctx, uid, func(ctx context.Context) {
+		e.verificationRoutine(ctx, uid, verification)
+	})
+}
+
+// startRoutine runs the routine function in a goroutine mapped to the UID, unless there is already a routine mapped to it
+func (e *Executer) startRoutine(ctx context.Context, uid types.UID, routineFunc func(ctx context.Context)) {
 	e.routinesLock.Lock()
 	defer e.routinesLock.Unlock()
 	if _, exist := e.routines[uid]; exist {
@@ -76,25 +181,95 @@ func (e *Executer) AsyncExecute(ctx context.Context, uid types.UID, command []st
 	}
 	e.routines[uid] = &routine
 
-	go e.fenceAgentRoutine(cancellableCtx, uid, command, retryCount, retryInterval, timeout)
+	// the routine stays mapped to the UID after it returns, until it is removed, thus it is uncounted once it returns
+	metrics.IncFenceAgentRoutines()
+	go func() {
+		defer metrics.DecFenceAgentRoutines()
+		routineFunc(cancellableCtx)
+	}()
 }
 
-func (e *Executer) fenceAgentRoutine(ctx context.Context, uid types.UID, command []string, retryCount int, retryInterval, timeout time.Duration) {
-	// run the command and update the status
-	retryErr, cmdErr := e.runWithRetry(ctx, uid, command, retryCount, retryInterval, timeout)
-	if retryErr != nil {
-		switch {
-		case errors.Is(retryErr, context.Canceled):
+func (e *Executer) fenceAgentRoutine(ctx context.Context, uid types.UID, levels []FencingLevel) {
+	// run the fencing levels in order until one of them succeeds, and update the status
+	var cmdErr error
+	var fencedBy *v1alpha1.FencingLevelStatus
+	var verification *Verification
+	for i, level := range levels {
+		var retryErr error
+		retryErr, cmdErr = e.runFencingLevel(ctx, uid, level)
+		if retryErr != nil {
+			switch {
+			case errors.Is(retryErr, context.Canceled):
+				e.log.Info(FenceAgentContextCanceledMessage)
+				return
+			case wait.Interrupted(retryErr):
+				e.log.Info(FenceAgentContextTimedOutMessage)
+			default:
+				e.log.Error(retryErr, FenceAgentRetryErrorMessage)
+			}
+		}
+		if cmdErr == nil {
+			fencedBy = &v1alpha1.FencingLevelStatus{Level: level.Number, Agent: level.agent()}
+			verification = level.Verification
+			break
+		}
+		if i < len(levels)-1 {
+			e.log.Info(FenceAgentFallbackMessage, "uid", uid, "level", level.Number, "fence_agent", level.agent(), "next fence_agent", levels[i+1].agent())
+		}
+	}
+
+	e.updateStatusWithRetryAndLog(ctx, uid, fenceAgentReason(cmdErr), func(far *v1alpha1.FenceAgentsRemediation) {
+		far.Status.FencedBy = fencedBy
+		far.Status.Execution = nil
+	})
+	if cmdErr != nil || verification == nil {
+		return
+	}
+
+	// the fence agent action has succeeded, verify that the node reached the expected power status
+	e.verificationRoutine(ctx, uid, verification)
+}
+
+func (e *Executer) verificationRoutine(ctx context.Context, uid types.UID, verification *Verification) {
+	reason := utils.FenceAgentVerificationSucceeded
+	if verifyErr := e.verifyPowerStatus(ctx, uid, verification); verifyErr != nil {
+		if ctx.Err() != nil {
+			e.log.Info(FenceAgentContextCanceledMessage)
+			return
+		}
+		e.log.Error(verifyErr, FenceAgentVerificationFailedMessage, "uid", uid)
+		reason = utils.FenceAgentVerificationFailed
+	}
+	e.updateStatusWithRetryAndLog(ctx, uid, reason)
+}
+
+func (e *Executer) powerOnRoutine(ctx context.Context, uid types.UID, level FencingLevel) {
+	retryErr, err := e.runFencingLevel(ctx, uid, level)
+	if errors.Is(retryErr, context.Canceled) {
+		e.log.Info(FenceAgentContextCanceledMessage)
+		return
+	}
+
+	if err == nil && level.Verification != nil {
+		if err = e.verifyPowerStatus(ctx, uid, level.Verification); err != nil && ctx.Err() != nil {
 			e.log.Info(FenceAgentContextCanceledMessage)
 			return
-		case wait.Interrupted(retryErr):
-			e.log.Info(FenceAgentContextTimedOutMessage)
-		default:
-			e.log.Error(retryErr, FenceAgentRetryErrorMessage)
 		}
 	}
 
-	if err := e.updateStatusWithRetry(ctx, uid, cmdErr); err != nil {
+	reason := utils.NodePowerOnSucceeded
+	if err != nil {
+		e.log.Error(err, "failed to power on the node", "uid", uid)
+		reason = utils.NodePowerOnFailed
+	}
+	e.updateStatusWithRetryAndLog(ctx, uid, reason, func(far *v1alpha1.FenceAgentsRemediation) {
+		far.Status.Execution = nil
+	})
+}
+
+// updateStatusWithRetryAndLog updates the status and logs the error, if any
+func (e *Executer) updateStatusWithRetryAndLog(ctx context.Context, uid types.UID, reason utils.ConditionsChangeReason, statusUpdates ...statusUpdateFunc) {
+	if err := e.updateStatusWithRetry(ctx, uid, reason, statusUpdates...); err != nil {
 		switch {
 		case wait.Interrupted(err):
 			e.log.Info("status context timed out")
@@ -104,7 +279,78 @@ func (e *Executer) fenceAgentRoutine(ctx context.Context, uid types.UID, command
 	}
 }
 
-func (e *Executer) runWithRetry(ctx context.Context, uid types.UID, command []string, retryCount int, retryInterval, timeout time.Duration) (retryErr, faErr error) {
+// runFencingLevel runs the commands of all the fencing level's devices, and then their power on commands,
+// and stops at the first device which fails
+func (e *Executer) runFencingLevel(ctx context.Context, uid types.UID, level FencingLevel) (retryErr, faErr error) {
+	for device, command := range level.Commands {
+		retryErr, faErr = e.runWithRetry(ctx, uid, level, command)
+		if retryErr != nil || faErr != nil {
+			if len(level.Commands) > 1 {
+				e.log.Info(FenceAgentDeviceFailedMessage, "uid", uid, "fence_agent", command[0], "device", device+1, "devices", len(level.Commands))
+			}
+			e.restorePoweredOffDevices(ctx, uid, level, device)
+			return retryErr, faErr
+		}
+	}
+	for device, command := range level.PowerOnCommands {
+		retryErr, faErr = e.runWithRetry(ctx, uid, level, command)
+		if retryErr != nil || faErr != nil {
+			if len(level.PowerOnCommands) > 1 {
+				e.log.Info(FenceAgentDeviceFailedMessage, "uid", uid, "fence_agent", command[0], "device", device+1, "devices", len(level.PowerOnCommands))
+			}
+			return retryErr, faErr
+		}
+	}
+	return nil, nil
+}
+
+// restorePoweredOffDevices powers on the devices of the fencing level which were powered off before the failed device, since the node
+// keeps running on its other power supplies, and the next fencing level shouldn't find it without redundant power. Devices which the
+// fencing level can't power on, i.e. when the fencing mode keeps the node powered off, are reported by an event instead.
+func (e *Executer) restorePoweredOffDevices(ctx context.Context, uid types.UID, level FencingLevel, failedDevice int) {
+	poweredOffDevices := 0
+	for _, command := range level.Commands[:failedDevice] {
+		if paramValue(command, []string{"--action"}) == offActionValue {
+			poweredOffDevices++
+		}
+	}
+	// a cancelled routine doesn't power on the devices, since the remediation is over
+	if poweredOffDevices == 0 || ctx.Err() != nil {
+		return
+	}
+
+	if len(level.PowerOnCommands) < failedDevice {
+		e.log.Info(FenceAgentPartialFencingMessage, "uid", uid, "level", level.Number, "failedDevice", failedDevice+1, "poweredOffDevices", poweredOffDevices)
+		e.warningEvent(ctx, uid, utils.EventReasonPartialFencing, fmt.Sprintf(utils.EventMessagePartialFencing, level.Number, failedDevice+1, poweredOffDevices))
+		return
+	}
+
+	e.log.Info("powering on the devices which were powered off before the failed device", "uid", uid, "level", level.Number, "failedDevice", failedDevice+1,
+		"poweredOffDevices", poweredOffDevices)
+	for device, command := range level.PowerOnCommands[:failedDevice] {
+		retryErr, faErr := e.runWithRetry(ctx, uid, level, command)
+		if retryErr != nil || faErr != nil {
+			e.log.Error(errors.Join(retryErr, faErr), "failed to power on the device which was powered off", "uid", uid, "level", level.Number, "device", device+1)
+			if ctx.Err() == nil {
+				e.warningEvent(ctx, uid, utils.EventReasonPartialFencing, fmt.Sprintf(utils.EventMessagePartialFencing, level.Number, failedDevice+1, poweredOffDevices))
+			}
+			return
+		}
+	}
+	e.warningEvent(ctx, uid, utils.EventReasonPartialFencing, fmt.Sprintf(utils.EventMessagePartialFencingRestored, level.Number, failedDevice+1, poweredOffDevices))
+}
+
+// warningEvent emits a warning event on the FAR with the given UID
+func (e *Executer) warningEvent(ctx context.Context, uid types.UID, reason, message string) {
+	far, err := e.getFenceAgentsRemediationByUID(ctx, uid)
+	if err != nil {
+		e.log.Error(err, "failed to emit an event", "uid", uid, "reason", reason)
+		return
+	}
+	commonEvents.WarningEvent(e.recorder, far, reason, message)
+}
+
+func (e *Executer) runWithRetry(ctx context.Context, uid types.UID, level FencingLevel, command []string) (retryErr, faErr error) {
 	// Run the command with an exponential backoff retry to handle the following cases:
 	// - the command fails: the command is retried until the retryCount is reached
 	// - the command times out: the command is retried until the retryCount is reached
@@ -112,21 +358,52 @@ func (e *Executer) runWithRetry(ctx context.Context, uid types.UID, command []st
 	// - the FA context is cancelled: the command is cancelled and the status is not updated
 	// - the command succeeds: the command is not retried and the status is updated
 
+	// the attempts which were made by a previous manager are deducted, but the interrupted attempt is retried
 	backoff := wait.Backoff{
-		Steps:    retryCount,
-		Duration: retryInterval,
+		Steps:    max(level.RetryCount-level.PreviousAttempts, 1),
+		Duration: level.RetryInterval,
 		Factor:   1.0,
 	}
+	attemptNumber := level.PreviousAttempts
 
-	e.log.Info("fence agent start", "uid", uid, "fence_agent", command[0], "retryCount", retryCount, "retryInterval", retryInterval, "timeout", timeout)
+	e.log.Info("fence agent start", "uid", uid, "fence_agent", command[0], "parametersOverStdin", level.ParametersOverStdin, "retryCount", level.RetryCount,
+		"previousAttempts", level.PreviousAttempts, "retryInterval", level.RetryInterval, "timeout", level.Timeout)
 
 	var stdout, stderr string
 	retryErr = wait.ExponentialBackoffWithContext(ctx,
 		backoff,
 		func(ctx context.Context) (bool, error) {
-			ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+			// wait for the fencing device, which might be busy with the commands of other nodes
+			release, queueWait, err := e.devices.acquire(ctx, command)
+			if err != nil {
+				return false, err
+			}
+			defer release()
+			metrics.ObserveDeviceQueueWait(command[0], queueWait)
+			if queueWait > 0 {
+				e.log.Info("fencing device was busy", "uid", uid, "fence_agent", command[0], "queueWait", queueWait)
+			}
+
+			// persist the attempt before running it, so another manager can take over the execution if this manager is gone
+			attemptNumber++
+			execution := &v1alpha1.FenceAgentExecution{Owner: e.Identity(), Level: level.Number, Attempt: attemptNumber, StartTime: metav1.Now()}
+			e.updateStatusWithRetryAndLog(ctx, uid, "", func(far *v1alpha1.FenceAgentsRemediation) {
+				far.Status.Execution = execution
+			})
+
+			ctxWithTimeout, cancel := context.WithTimeout(ctx, level.Timeout)
 			defer cancel()
-			stdout, stderr, faErr = e.runner(ctxWithTimeout, command)
+			start := time.Now()
+			stdout, stderr, faErr = e.runner(ctxWithTimeout, command, level.ParametersOverStdin)
+			end := time.Now()
+			metrics.ObserveFenceAgentAttempt(ctx, ctxWithTimeout, command[0], end.Sub(start), faErr)
+			// a cancelled routine doesn't update the status
+			if ctx.Err() == nil {
+				attempt := newAttempt(ctxWithTimeout, level.Number, attemptNumber, command, start, end, queueWait, stdout, stderr, faErr)
+				e.updateStatusWithRetryAndLog(ctx, uid, "", func(far *v1alpha1.FenceAgentsRemediation) {
+					far.Status.Attempts = appendAttempt(far.Status.Attempts, attempt)
+				})
+			}
 			if faErr == nil {
 				e.log.Info("command completed", "uid", uid, "response", stdout, "errMessage", stderr, "err", faErr)
 				return true, nil
@@ -145,7 +422,107 @@ func (e *Executer) runWithRetry(ctx context.Context, uid types.UID, command []st
 	return retryErr, faErr
 }
 
-func (e *Executer) updateStatusWithRetry(ctx context.Context, uid types.UID, fenceAgentErr error) error {
+// verifyPowerStatus runs the fence agent status command until it reports the expected power status, or until the verification timeout expires
+func (e *Executer) verifyPowerStatus(ctx context.Context, uid types.UID, verification *Verification) error {
+	e.log.Info("fence agent verification start", "uid", uid, "fence_agent", verification.Commands[0][0], "devices", len(verification.Commands),
+		"expectedStatus", verification.ExpectedStatus, "timeout", verification.Timeout, "interval", verification.Interval)
+
+	var lastStatus string
+	err := wait.PollUntilContextTimeout(ctx, verification.Interval, verification.Timeout, true, func(ctx context.Context) (bool, error) {
+		// every fencing device has to report the expected power status
+		for device, command := range verification.Commands {
+			stdout, stderr, err := e.runOnDevice(ctx, command, verification.ParametersOverStdin)
+			// the status action might exit with a non-zero code on purpose, e.g. when the power status is OFF, hence rely on its output
+			lastStatus = parsePowerStatus(stdout)
+			if lastStatus != verification.ExpectedStatus {
+				e.log.Info(FenceAgentUnexpectedStatusMessage, "uid", uid, "device", device+1, "status", lastStatus, "expectedStatus", verification.ExpectedStatus,
+					"response", stdout, "errMessage", stderr, "err", err)
+				return false, nil
+			}
+		}
+		e.log.Info("power status verified", "uid", uid, "status", lastStatus)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("expected power status %s, last reported power status was `%s`: %w", verification.ExpectedStatus, lastStatus, err)
+	}
+	return nil
+}
+
+// CheckPowerStatus runs the fence agent status command once, and returns the reported power status and how long the fence agent took to report it.
+// It doesn't change the node's power status, thus it can be used for testing the fence agent parameters.
+// It waits up to maxCheckDeviceWait for a busy fencing device, e.g. while other nodes of the same PDU are checked, and returns ErrDeviceBusy
+// if the device stays busy. The fencing commands which wait for the device get it before the checks, so the checks don't delay the fencing of the nodes.
+func (e *Executer) CheckPowerStatus(ctx context.Context, command []string, parametersOverStdin bool, timeout time.Duration) (string, time.Duration, error) {
+	release, err := e.devices.acquireForCheck(ctx, command, maxCheckDeviceWait)
+	if err != nil {
+		return "", 0, err
+	}
+	defer release()
+	return e.runStatusCommand(ctx, command, parametersOverStdin, timeout)
+}
+
+// GetPowerStatus runs the fence agent status command once, like CheckPowerStatus, and returns the reported power status.
+// It waits for a busy fencing device like the fencing commands, since it is a part of the remediation.
+func (e *Executer) GetPowerStatus(ctx context.Context, command []string, parametersOverStdin bool, timeout time.Duration) (string, error) {
+	// the time spent waiting for a busy fencing device doesn't count for the timeout
+	release, queueWait, err := e.devices.acquire(ctx, command)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	metrics.ObserveDeviceQueueWait(command[0], queueWait)
+	powerStatus, _, err := e.runStatusCommand(ctx, command, parametersOverStdin, timeout)
+	return powerStatus, err
+}
+
+// runStatusCommand runs the fence agent status command once, and returns the reported power status and how long the fence agent took to report it
+func (e *Executer) runStatusCommand(ctx context.Context, command []string, parametersOverStdin bool, timeout time.Duration) (string, time.Duration, error) {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	stdout, stderr, err := e.runner(ctxWithTimeout, command, parametersOverStdin)
+	latency := time.Since(start)
+	// the status action might exit with a non-zero code on purpose, e.g. when the power status is OFF, hence rely on its output
+	if status := parsePowerStatus(stdout); status != "" {
+		return status, latency, nil
+	}
+
+	if err == nil {
+		err = errors.New("no power status was reported")
+	}
+	// the error is stored in the status, hence the stderr is redacted and cut like the stderr of the fence agent attempts
+	if stderr = outputExcerpt(redactOutput(stderr, command)); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+	return "", latency, err
+}
+
+// runOnDevice runs the command once the command's fencing device isn't busy
+func (e *Executer) runOnDevice(ctx context.Context, command []string, parametersOverStdin bool) (string, string, error) {
+	release, queueWait, err := e.devices.acquire(ctx, command)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+	metrics.ObserveDeviceQueueWait(command[0], queueWait)
+	return e.runner(ctx, command, parametersOverStdin)
+}
+
+// parsePowerStatus returns the power status from the fence agent status action output, or an empty string if it can't be found
+func parsePowerStatus(stdout string) string {
+	match := powerStatusRegex.FindStringSubmatch(stdout)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// statusUpdateFunc applies a change on the FAR status, in addition to the conditions change
+type statusUpdateFunc func(far *v1alpha1.FenceAgentsRemediation)
+
+func (e *Executer) updateStatusWithRetry(ctx context.Context, uid types.UID, reason utils.ConditionsChangeReason, statusUpdates ...statusUpdateFunc) error {
 	// Update FAR status with an exponential backoff retry to handle only the updateStatus error cases where:
 	// - FAR cannot be found, but it does exist
 	// - the status update fails for conflicts
@@ -171,7 +548,7 @@ func (e *Executer) updateStatusWithRetry(ctx context.Context, uid types.UID, fen
 				return false, err
 			}
 
-			if err := e.updateStatus(ctx, far, fenceAgentErr); err != nil {
+			if err := e.updateStatus(ctx, far, reason, statusUpdates...); err != nil {
 				if wait.Interrupted(err) {
 					e.log.Info("context cancelled while updating the status", "FAR uid", uid)
 					return false, err
@@ -208,9 +585,20 @@ func (e *Executer) Remove(uid types.UID) {
 	}
 }
 
-// run runs the command in the container and updates the status of the FAR instance maching the UID
-func run(ctx context.Context, command []string) (stdout, stderr string, err error) {
+// run runs the command in the container and updates the status of the FAR instance maching the UID.
+// When the parameters are passed over stdin, only the fence agent name is on the command line, so the parameters,
+// and especially the ones coming from Secrets, aren't visible in /proc/<pid>/cmdline
+func run(ctx context.Context, command []string, parametersOverStdin bool) (stdout, stderr string, err error) {
 	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	if parametersOverStdin {
+		stdinParams, err := buildStdinParams(command[1:], getStdinNames(command[0]))
+		if err != nil {
+			return "", "", err
+		}
+		// the agents read stdin only when there are no command line parameters
+		cmd = exec.CommandContext(ctx, command[0])
+		cmd.Stdin = strings.NewReader(stdinParams)
+	}
 
 	var outBuilder, errBuilder strings.Builder
 	cmd.Stdout = &outBuilder
@@ -240,18 +628,36 @@ func (e *Executer) getFenceAgentsRemediationByUID(ctx context.Context, uid types
 	return nil, err
 }
 
-func (e *Executer) updateStatus(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, err error) error {
-	var reason utils.ConditionsChangeReason
-
+// fenceAgentReason returns the conditions change reason matching the fence agent command error
+func fenceAgentReason(err error) utils.ConditionsChangeReason {
 	if err == nil {
-		reason = utils.FenceAgentSucceeded
-		commonEvents.NormalEvent(e.recorder, far, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+		return utils.FenceAgentSucceeded
 	} else if wait.Interrupted(err) {
-		reason = utils.FenceAgentTimedOut
-	} else {
-		reason = utils.FenceAgentFailed
+		return utils.FenceAgentTimedOut
+	}
+	return utils.FenceAgentFailed
+}
+
+func (e *Executer) updateStatus(ctx context.Context, far *v1alpha1.FenceAgentsRemediation, reason utils.ConditionsChangeReason, statusUpdates ...statusUpdateFunc) error {
+	switch reason {
+	case utils.FenceAgentSucceeded:
+		commonEvents.NormalEvent(e.recorder, far, utils.EventReasonFenceAgentSucceeded, utils.EventMessageFenceAgentSucceeded)
+	case utils.FenceAgentVerificationSucceeded:
+		commonEvents.NormalEvent(e.recorder, far, utils.EventReasonFenceAgentVerified, utils.EventMessageFenceAgentVerified)
+	case utils.FenceAgentVerificationFailed:
+		commonEvents.WarningEvent(e.recorder, far, utils.EventReasonFenceAgentNotVerified, utils.EventMessageFenceAgentNotVerified)
+	case utils.NodePowerOnSucceeded:
+		commonEvents.NormalEvent(e.recorder, far, utils.EventReasonNodePoweredOn, utils.EventMessageNodePoweredOn)
+	case utils.NodePowerOnFailed:
+		commonEvents.WarningEvent(e.recorder, far, utils.EventReasonNodePowerOnFailed, utils.EventMessageNodePowerOnFailed)
 	}
 
-	utils.UpdateConditions(reason, far, e.log)
+	for _, statusUpdate := range statusUpdates {
+		statusUpdate(far)
+	}
+	// an empty reason updates the status without changing the conditions
+	if reason != "" {
+		utils.UpdateConditions(reason, far, e.log)
+	}
 	return e.Status().Update(ctx, far)
 }
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+	"github.com/medik8s/fence-agents-remediation/pkg/utils"
+)
+
+func Test_runFencingLevelRestoresPoweredOffDevices(t *testing.T) {
+	device1 := []string{"fence_apc_snmp", "--ip=192.168.111.10", "--plug=3"}
+	device2 := []string{"fence_apc_snmp", "--ip=192.168.111.11", "--plug=5"}
+	withAction := func(device []string, action string) []string {
+		return append(slices.Clone(device), "--action="+action)
+	}
+	multiDeviceReboot := FencingLevel{
+		Number:          1,
+		Commands:        [][]string{withAction(device1, "off"), withAction(device2, "off")},
+		PowerOnCommands: [][]string{withAction(device1, "on"), withAction(device2, "on")},
+		RetryCount:      1,
+		Timeout:         time.Second,
+	}
+	multiDeviceOff := multiDeviceReboot
+	multiDeviceOff.PowerOnCommands = nil
+
+	tests := []struct {
+		name         string
+		level        FencingLevel
+		wantCommands [][]string
+		wantEvent    string
+	}{
+		{
+			name:         "powerOnCommands",
+			level:        multiDeviceReboot,
+			wantCommands: [][]string{withAction(device1, "off"), withAction(device2, "off"), withAction(device1, "on")},
+			wantEvent:    "Warning " + utils.EventReasonPartialFencing + " [remediation] Fencing level 1 failed at device 2, thus 1 of its devices which were already powered off were powered on again",
+		},
+		{
+			name:         "noPowerOnCommands",
+			level:        multiDeviceOff,
+			wantCommands: [][]string{withAction(device1, "off"), withAction(device2, "off")},
+			wantEvent:    "Warning " + utils.EventReasonPartialFencing + " [remediation] Fencing level 1 failed at device 2, while 1 of its devices which were already powered off remain powered off",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			far := &v1alpha1.FenceAgentsRemediation{ObjectMeta: metav1.ObjectMeta{Name: "worker-0", UID: "far-uid"}}
+			recorder := record.NewFakeRecorder(10)
+			var commands [][]string
+			e := NewFakeExecuter(&statusClient{far: far}, func(_ context.Context, command []string, _ bool) (string, string, error) {
+				commands = append(commands, command)
+				// the second device can't be powered off
+				if slices.Contains(command, "--ip=192.168.111.11") && slices.Contains(command, "--action=off") {
+					return "", "Failed: Unable to connect", errors.New("exit status 1")
+				}
+				return "Success: Rebooted", "", nil
+			}, recorder)
+
+			if _, faErr := e.runFencingLevel(context.Background(), far.UID, tt.level); faErr == nil {
+				t.Fatal("runFencingLevel() of a failing device didn't fail")
+			}
+			if !slices.EqualFunc(commands, tt.wantCommands, slices.Equal[[]string]) {
+				t.Errorf("runFencingLevel() ran %v, want %v", commands, tt.wantCommands)
+			}
+			select {
+			case event := <-recorder.Events:
+				if event != tt.wantEvent {
+					t.Errorf("runFencingLevel() emitted %q, want %q", event, tt.wantEvent)
+				}
+			default:
+				t.Errorf("runFencingLevel() didn't emit %q", tt.wantEvent)
+			}
+			// the power on of the restored devices is recorded like the other attempts
+			if len(far.Status.Attempts) != len(tt.wantCommands) {
+				t.Errorf("runFencingLevel() recorded %d attempts, want %d", len(far.Status.Attempts), len(tt.wantCommands))
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxDeviceSessions is the default number of fence agent commands which can run against the same fencing device at the same time
+	DefaultMaxDeviceSessions = 1
+	// checkPollInterval is the interval between the attempts of a power status check to get a session of a busy fencing device
+	checkPollInterval = 100 * time.Millisecond
+)
+
+var (
+	// deviceAddressParams are the fence agent parameters which hold the fencing device address
+	deviceAddressParams = []string{"--ip", "--ipaddr", "-a"}
+	// devicePortParams are the fence agent parameters which hold the fencing device TCP port,
+	// unlike --plug and --port which identify the node's outlet or VM on the device
+	devicePortParams = []string{"--ipport", "-u"}
+)
+
+// ErrDeviceBusy is returned by the power status checks when the fencing device stays busy for longer than they wait for it
+var ErrDeviceBusy = errors.New("the fencing device is busy")
+
+// deviceLimiter limits the number of fence agent commands which run against the same fencing device at the same time,
+// since devices like PDUs and chassis management modules, which are shared by many nodes, reject concurrent sessions
+type deviceLimiter struct {
+	maxSessions int
+	devices     map[string]*deviceSessions
+	lock        sync.Mutex
+}
+
+// deviceSessions holds the sessions of a fencing device, and the number of commands which hold or wait for them,
+// so the device is forgotten once no command uses it
+type deviceSessions struct {
+	sessions chan struct{}
+	users    int
+	waiting  int
+}
+
+// newDeviceLimiter builds a deviceLimiter which allows up to maxSessions concurrent commands per fencing device
+func newDeviceLimiter(maxSessions int) *deviceLimiter {
+	return &deviceLimiter{
+		maxSessions: max(maxSessions, 1),
+		devices:     make(map[string]*deviceSessions),
+	}
+}
+
+// acquire waits until a session of the command's fencing device is available, and returns the function which releases the session
+// and the time it has waited. Commands without a device address aren't limited.
+func (l *deviceLimiter) acquire(ctx context.Context, command []string) (func(), time.Duration, error) {
+	key := deviceKey(command)
+	if key == "" {
+		return func() {}, 0, nil
+	}
+
+	l.lock.Lock()
+	device := l.getDevice(key)
+	device.waiting++
+	l.lock.Unlock()
+
+	start := time.Now()
+	select {
+	case device.sessions <- struct{}{}:
+		l.lock.Lock()
+		device.waiting--
+		l.lock.Unlock()
+		return l.releaseFunc(key, device), time.Since(start), nil
+	case <-ctx.Done():
+		l.lock.Lock()
+		device.waiting--
+		l.putDevice(key, device)
+		l.lock.Unlock()
+		return nil, time.Since(start), ctx.Err()
+	}
+}
+
+// tryAcquire returns the function which releases a session of the command's fencing device if one is available, and ErrDeviceBusy
+// otherwise. A session isn't available while commands are waiting in acquire, thus the fencing commands aren't starved by the checks.
+func (l *deviceLimiter) tryAcquire(command []string) (func(), error) {
+	key := deviceKey(command)
+	if key == "" {
+		return func() {}, nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	device := l.getDevice(key)
+	if device.waiting == 0 {
+		select {
+		case device.sessions <- struct{}{}:
+			return l.releaseFunc(key, device), nil
+		default:
+		}
+	}
+	l.putDevice(key, device)
+	return nil, ErrDeviceBusy
+}
+
+// acquireForCheck waits up to maxWait until a session of the command's fencing device is available like tryAcquire, and returns
+// the function which releases the session, or ErrDeviceBusy if the device stays busy. The checks poll for the session rather than
+// wait in acquire, thus the fencing commands which wait for the device still get it first.
+func (l *deviceLimiter) acquireForCheck(ctx context.Context, command []string, maxWait time.Duration) (func(), error) {
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	ticker := time.NewTicker(checkPollInterval)
+	defer ticker.Stop()
+	for {
+		release, err := l.tryAcquire(command)
+		if err == nil {
+			return release, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return nil, ErrDeviceBusy
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// getDevice returns the sessions of the fencing device, and counts a new user of them. It is called with the lock held.
+func (l *deviceLimiter) getDevice(key string) *deviceSessions {
+	device, exist := l.devices[key]
+	if !exist {
+		device = &deviceSessions{sessions: make(chan struct{}, l.maxSessions)}
+		l.devices[key] = device
+	}
+	device.users++
+	return device
+}
+
+// putDevice uncounts a user of the fencing device's sessions, and forgets the device once it has no users. It is called with the lock held.
+func (l *deviceLimiter) putDevice(key string, device *deviceSessions) {
+	if device.users--; device.users == 0 {
+		delete(l.devices, key)
+	}
+}
+
+// releaseFunc returns the function which releases the session of the fencing device
+func (l *deviceLimiter) releaseFunc(key string, device *deviceSessions) func() {
+	return func() {
+		<-device.sessions
+		l.lock.Lock()
+		l.putDevice(key, device)
+		l.lock.Unlock()
+	}
+}
+
+// deviceKey returns the fencing device address and port of the command, e.g. 192.168.111.1:623, or an empty string
+// if the command has no device address
+func deviceKey(command []string) string {
+	address := paramValue(command, deviceAddressParams)
+	if address == "" {
+		return ""
+	}
+	if port := paramValue(command, devicePortParams); port != "" {
+		return address + ":" + port
+	}
+	return address
+}
+
+// paramValue returns the value of the first of the names which is found in the command parameters, in the --name=value form
+func paramValue(command []string, names []string) string {
+	for _, name := range names {
+		for _, param := range command[1:] {
+			if value, found := strings.CutPrefix(param, name+"="); found {
+				return value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_deviceKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    string
+	}{
+		{name: "addressAndPort", command: []string{"fence_ipmilan", "--ip=192.168.111.1", "--ipport=6233", "--lanplus"}, want: "192.168.111.1:6233"},
+		{name: "addressOnly", command: []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=3"}, want: "pdu.example.com"},
+		{name: "addressAlias", command: []string{"fence_apc_snmp", "--ipaddr=pdu.example.com", "-u=161"}, want: "pdu.example.com:161"},
+		{name: "noAddress", command: []string{"fence_aws", "--region=us-east-1", "--plug=i-0123"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceKey(tt.command); got != tt.want {
+				t.Errorf("deviceKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deviceLimiter(t *testing.T) {
+	limiter := newDeviceLimiter(1)
+	command := []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=3"}
+	otherNodeCommand := []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=4"}
+	otherDeviceCommand := []string{"fence_apc_snmp", "--ip=pdu2.example.com", "--plug=3"}
+
+	release, queueWait, err := limiter.acquire(context.Background(), command)
+	if err != nil || queueWait > time.Second {
+		t.Fatalf("acquire() of an idle device waited %v, err %v", queueWait, err)
+	}
+
+	// another device isn't affected
+	releaseOtherDevice, _, err := limiter.acquire(context.Background(), otherDeviceCommand)
+	if err != nil {
+		t.Fatalf("acquire() of another device failed: %v", err)
+	}
+	releaseOtherDevice()
+
+	// the device is busy
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, _, err = limiter.acquire(ctx, otherNodeCommand); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() of a busy device returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// the device is available once it is released
+	time.AfterFunc(100*time.Millisecond, release)
+	releaseOtherNode, queueWait, err := limiter.acquire(context.Background(), otherNodeCommand)
+	if err != nil || queueWait < 100*time.Millisecond {
+		t.Fatalf("acquire() of a released device waited %v, err %v", queueWait, err)
+	}
+	releaseOtherNode()
+}
+
+func Test_deviceLimiterPrefersFencing(t *testing.T) {
+	limiter := newDeviceLimiter(1)
+	fenceCommand := []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=3", "--action=reboot"}
+	checkCommand := []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=4", "--action=status"}
+
+	// a check holds the device while the fence command waits for it
+	releaseCheck, err := limiter.tryAcquire(checkCommand)
+	if err != nil {
+		t.Fatalf("tryAcquire() of an idle device failed: %v", err)
+	}
+	acquired := make(chan func())
+	go func() {
+		release, _, _ := limiter.acquire(context.Background(), fenceCommand)
+		acquired <- release
+	}()
+	for !isWaiting(limiter, deviceKey(fenceCommand)) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the following checks don't take the device from the waiting fence command
+	releaseCheck()
+	for i := 0; i < 100; i++ {
+		if release, err := limiter.tryAcquire(checkCommand); err == nil {
+			release()
+			select {
+			case releaseFence := <-acquired:
+				releaseFence()
+				t.Fatalf("tryAcquire() took the device after the fence command")
+			default:
+				t.Fatalf("tryAcquire() took the device while a fence command was waiting")
+			}
+		} else if !errors.Is(err, ErrDeviceBusy) {
+			t.Fatalf("tryAcquire() returned %v, want %v", err, ErrDeviceBusy)
+		}
+	}
+	select {
+	case releaseFence := <-acquired:
+		releaseFence()
+	case <-time.After(time.Second):
+		t.Fatal("the fence command didn't get the device")
+	}
+}
+
+func Test_CheckPowerStatusSharedDevice(t *testing.T) {
+	const nodes = 5
+	var running, maxRunning atomic.Int32
+	e := NewFakeExecuter(nil, func(context.Context, []string, bool) (string, string, error) {
+		if current := running.Add(1); current > maxRunning.Load() {
+			maxRunning.Store(current)
+		}
+		defer running.Add(-1)
+		time.Sleep(50 * time.Millisecond)
+		return "Status: ON\n", "", nil
+	}, nil)
+
+	// the nodes of a PDU are checked at the same time, like the fencing checks of a template do
+	errs := make(chan error, nodes)
+	var wg sync.WaitGroup
+	for i := 1; i <= nodes; i++ {
+		wg.Add(1)
+		go func(plug int) {
+			defer wg.Done()
+			command := []string{"fence_apc_snmp", "--ip=pdu.example.com", fmt.Sprintf("--plug=%d", plug), "--action=status"}
+			powerStatus, _, err := e.CheckPowerStatus(context.Background(), command, false, time.Second)
+			if err == nil && powerStatus != PowerStatusOn {
+				err = fmt.Errorf("power status %q, want %q", powerStatus, PowerStatusOn)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("CheckPowerStatus() of a node which shares the fencing device failed: %v", err)
+		}
+	}
+	if maxRunning.Load() != DefaultMaxDeviceSessions {
+		t.Errorf("%d checks ran against the fencing device at the same time, want %d", maxRunning.Load(), DefaultMaxDeviceSessions)
+	}
+}
+
+func Test_deviceLimiterForgetsDevices(t *testing.T) {
+	limiter := newDeviceLimiter(1)
+	command := []string{"fence_apc_snmp", "--ip=pdu.example.com", "--plug=3"}
+
+	release, _, err := limiter.acquire(context.Background(), command)
+	if err != nil {
+		t.Fatalf("acquire() of an idle device failed: %v", err)
+	}
+	if _, err := limiter.tryAcquire(command); !errors.Is(err, ErrDeviceBusy) {
+		t.Fatalf("tryAcquire() of a busy device returned %v, want %v", err, ErrDeviceBusy)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := limiter.acquire(ctx, command); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() of a busy device returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	release()
+
+	if len(limiter.devices) != 0 {
+		t.Errorf("deviceLimiter keeps %d devices without users, want 0", len(limiter.devices))
+	}
+}
+
+// isWaiting checks whether a command waits for the device in acquire
+func isWaiting(limiter *deviceLimiter, key string) bool {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	device, exist := limiter.devices[key]
+	return exist && device.waiting > 0
+}
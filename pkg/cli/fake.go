@@ -13,8 +13,21 @@ func NewFakeExecuter(client client.Client, fn runnerFunc, fakeRecorder *record.F
 	return &Executer{
 		Client:   client,
 		log:      logger,
+		identity: "fake-executer",
 		routines: make(map[types.UID]*routine),
 		runner:   fn,
 		recorder: fakeRecorder,
+		devices:  newDeviceLimiter(DefaultMaxDeviceSessions),
+	}
+}
+
+// SimulateRestart simulates a manager restart for testing: the routines are cancelled without updating the status, as if they were
+// killed with the manager
+func (e *Executer) SimulateRestart() {
+	e.routinesLock.Lock()
+	defer e.routinesLock.Unlock()
+	for uid, routine := range e.routines {
+		routine.cancel()
+		delete(e.routines, uid)
 	}
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/medik8s/fence-agents-remediation/pkg/validation"
+)
+
+var (
+	// stdinNamesCache maps the fence agent name to its *stdinNamesEntry, since the metadata of an agent doesn't change
+	stdinNamesCache sync.Map
+	// getAgentMetadata returns the metadata XML of the fence agent. It is configurable for testing purposes
+	getAgentMetadata validation.AgentMetadataGetter = validation.GetAgentMetadata
+)
+
+// stdinNamesEntry holds the stdin names of a fence agent, which are read once, even when the agent metadata is unavailable
+type stdinNamesEntry struct {
+	once       sync.Once
+	stdinNames map[string]string
+}
+
+// getStdinNames returns the fence agent long options mapped to their stdin names, based on the agent metadata.
+// The metadata of each agent is read only once, and when it is unavailable, the failure is logged and no stdin names are returned,
+// thus the options are passed with their long option names, which the agents accept as well.
+func getStdinNames(agent string) map[string]string {
+	value, _ := stdinNamesCache.LoadOrStore(agent, &stdinNamesEntry{})
+	entry := value.(*stdinNamesEntry)
+	entry.once.Do(func() {
+		stdinNames, err := readStdinNames(agent)
+		if err != nil {
+			ctrl.Log.WithName("executer").Error(err, "Failed to get the stdin names of the fence agent parameters, thus the long option names are used",
+				"fence_agent", agent)
+			return
+		}
+		entry.stdinNames = stdinNames
+	})
+	return entry.stdinNames
+}
+
+// readStdinNames reads the stdin names of the fence agent long options from the agent metadata
+func readStdinNames(agent string) (map[string]string, error) {
+	metadata, err := getAgentMetadata(agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the metadata of fence agent %s: %w", agent, err)
+	}
+	schema, err := validation.ParseAgentMetadata(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the metadata of fence agent %s: %w", agent, err)
+	}
+	return schema.StdinNames(), nil
+}
+
+// buildStdinParams converts the command line parameters to the `name=value` lines which the fence agent reads from stdin.
+// Options which are missing from stdinNames are passed with their long option name, which the agents accept as well.
+func buildStdinParams(params []string, stdinNames map[string]string) (string, error) {
+	var stdinParams strings.Builder
+	for _, param := range params {
+		if !strings.HasPrefix(param, "--") {
+			return "", fmt.Errorf("parameter %s can't be passed over stdin, only long options are supported", param)
+		}
+		option, value, hasValue := strings.Cut(strings.TrimPrefix(param, "--"), "=")
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("the value of parameter --%s can't be passed over stdin, since it has multiple lines", option)
+		}
+		name, isFound := stdinNames[option]
+		if !isFound {
+			name = option
+		}
+		if !hasValue {
+			// a parameter without a value is a flag, which is set on stdin by a true value
+			value = "1"
+		}
+		stdinParams.WriteString(name + "=" + value + "\n")
+	}
+	return stdinParams.String(), nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/medik8s/fence-agents-remediation/pkg/validation"
+)
+
+const testMetadata = `<?xml version="1.0" ?>
+<resource-agent name="fence_ipmilan" shortdesc="Fence agent for IPMI" >
+<parameters>
+	<parameter name="ipport" unique="0" required="0">
+		<getopt mixed="-u, --ipport=[port]" />
+	</parameter>
+	<parameter name="lanplus" unique="0" required="0">
+		<getopt mixed="-P, --lanplus" />
+	</parameter>
+	<parameter name="login" unique="0" required="0" deprecated="1">
+		<getopt mixed="-l, --username=[name]" />
+	</parameter>
+	<parameter name="password" unique="0" required="0">
+		<getopt mixed="-p, --password=[password]" />
+	</parameter>
+	<parameter name="ssl_insecure" unique="0" required="0">
+		<getopt mixed="--ssl-insecure" />
+	</parameter>
+	<parameter name="username" unique="0" required="0" obsoletes="login">
+		<getopt mixed="-l, --username=[name]" />
+	</parameter>
+</parameters>
+</resource-agent>`
+
+func Test_buildStdinParams(t *testing.T) {
+	schema, err := validation.ParseAgentMetadata([]byte(testMetadata))
+	if err != nil {
+		t.Fatalf("ParseAgentMetadata() error = %v", err)
+	}
+	stdinNames := schema.StdinNames()
+
+	tests := []struct {
+		name    string
+		params  []string
+		want    string
+		wantErr bool
+	}{
+		//valid use-cases
+		{name: "valueParam", params: []string{"--password=pass=word"}, want: "password=pass=word\n"},
+		{name: "flagParam", params: []string{"--lanplus"}, want: "lanplus=1\n"},
+		{name: "deprecatedParam", params: []string{"--username=admin"}, want: "username=admin\n"},
+		{name: "dashedParam", params: []string{"--ssl-insecure"}, want: "ssl_insecure=1\n"},
+		{name: "unknownParam", params: []string{"--unknown=value"}, want: "unknown=value\n"},
+		{name: "multipleParams", params: []string{"--ipport=6233", "--action=reboot"}, want: "ipport=6233\naction=reboot\n"},
+
+		//invalid use-cases
+		{name: "shortParam", params: []string{"-p"}, wantErr: true},
+		{name: "multiLineValue", params: []string{"--password=pass\nword"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildStdinParams(tt.params, stdinNames)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("buildStdinParams() = %q, error = %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_getStdinNames(t *testing.T) {
+	var calls atomic.Int32
+	getAgentMetadata = func(agent string) ([]byte, error) {
+		calls.Add(1)
+		if agent == "fence_missing" {
+			return nil, errors.New("exit status 127")
+		}
+		return []byte(testMetadata), nil
+	}
+	defer func() { getAgentMetadata = validation.GetAgentMetadata }()
+
+	for i := 0; i < 3; i++ {
+		if stdinNames := getStdinNames("fence_test_ipmilan"); stdinNames["ipport"] != "ipport" || stdinNames["ssl-insecure"] != "ssl_insecure" {
+			t.Errorf("getStdinNames() = %v, want the stdin names of the metadata", stdinNames)
+		}
+		// the failure is cached as well
+		if stdinNames := getStdinNames("fence_missing"); stdinNames != nil {
+			t.Errorf("getStdinNames() of an agent without metadata = %v, want nil", stdinNames)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("the agents metadata was read %d times, want once per agent", got)
+	}
+}
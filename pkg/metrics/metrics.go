@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// fence agent attempt results
+	AttemptResultSuccess  = "success"
+	AttemptResultFailed   = "failed"
+	AttemptResultTimedOut = "timed-out"
+	AttemptResultCanceled = "canceled"
+)
+
+var (
+	// fenceAgentAttempts counts the fence agent command executions, by agent and result
+	fenceAgentAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "far_fence_agent_attempts_total",
+		Help: "Number of fence agent command attempts, by agent and result",
+	}, []string{"agent", "result"})
+
+	// fenceAgentAttemptDuration is the duration of each fence agent command execution, by agent
+	fenceAgentAttemptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "far_fence_agent_attempt_duration_seconds",
+		Help:    "Duration of fence agent command attempts in seconds, by agent",
+		Buckets: []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"agent"})
+
+	// remediationDuration is the duration of successful remediations, from their start until they are finished
+	remediationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "far_remediation_duration_seconds",
+		Help:    "Duration of successful remediations in seconds, from the remediation start until it is finished",
+		Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	})
+
+	// fenceAgentRoutines is the number of fence agent routines which are running, from their start until their goroutine returns
+	fenceAgentRoutines = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "far_fence_agent_routines_in_flight",
+		Help: "Number of fence agent routines which are running",
+	})
+
+	// remediationPodsDeleted is the number of pods which were deleted by each remediation
+	remediationPodsDeleted = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "far_remediation_pods_deleted",
+		Help:    "Number of pods which were deleted from the remediated node, per remediation",
+		Buckets: []float64{0, 1, 5, 10, 20, 50, 100, 250},
+	})
+
+	// deviceQueueWait is the time each fence agent command waited for its fencing device, by agent
+	deviceQueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "far_fencing_device_queue_wait_seconds",
+		Help:    "Time fence agent commands waited for their busy fencing device in seconds, by agent",
+		Buckets: []float64{0, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"agent"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(fenceAgentAttempts, fenceAgentAttemptDuration, remediationDuration, fenceAgentRoutines, remediationPodsDeleted, deviceQueueWait)
+}
+
+// ObserveFenceAgentAttempt records the result and duration of a fence agent command attempt.
+// The result is derived from the attempt error and from the contexts of the whole fencing and of the attempt.
+func ObserveFenceAgentAttempt(ctx, attemptCtx context.Context, agent string, duration time.Duration, err error) {
+	result := AttemptResultSuccess
+	if err != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			result = AttemptResultCanceled
+		case errors.Is(attemptCtx.Err(), context.DeadlineExceeded):
+			result = AttemptResultTimedOut
+		default:
+			result = AttemptResultFailed
+		}
+	}
+	fenceAgentAttempts.WithLabelValues(agent, result).Inc()
+	fenceAgentAttemptDuration.WithLabelValues(agent).Observe(duration.Seconds())
+}
+
+// ObserveRemediationDuration records the duration of a successful remediation
+func ObserveRemediationDuration(duration time.Duration) {
+	remediationDuration.Observe(duration.Seconds())
+}
+
+// IncFenceAgentRoutines counts a fence agent routine which has started
+func IncFenceAgentRoutines() {
+	fenceAgentRoutines.Inc()
+}
+
+// DecFenceAgentRoutines uncounts a fence agent routine which has returned
+func DecFenceAgentRoutines() {
+	fenceAgentRoutines.Dec()
+}
+
+// ObserveRemediationPodsDeleted records the number of pods which were deleted by a remediation
+func ObserveRemediationPodsDeleted(pods int) {
+	remediationPodsDeleted.Observe(float64(pods))
+}
+
+// ObserveDeviceQueueWait records the time a fence agent command waited for its fencing device
+func ObserveDeviceQueueWait(agent string, wait time.Duration) {
+	deviceQueueWait.WithLabelValues(agent).Observe(wait.Seconds())
+}
@@ -0,0 +1,121 @@
+package nodetemplate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	fieldName        = "Name"
+	fieldProviderID  = "ProviderID"
+	fieldLabels      = "Labels"
+	fieldAnnotations = "Annotations"
+	fieldAddresses   = "Addresses"
+)
+
+var (
+	// placeholder matches a {{ ... }} placeholder of a template
+	placeholder = regexp.MustCompile(`{{(.*?)}}`)
+	// scalarField matches a node field, e.g. .Node.ProviderID
+	scalarField = regexp.MustCompile(`^\.Node\.(` + fieldName + `|` + fieldProviderID + `)$`)
+	// mapField matches a key of a node map field, e.g. .Node.Annotations["bmc.example.com/address"], or .Node.Addresses["InternalIP"] for the node's address of that type
+	mapField = regexp.MustCompile(`^\.Node\.(` + fieldLabels + `|` + fieldAnnotations + `|` + fieldAddresses + `)\["([^"]+)"\]$`)
+)
+
+// IsTemplate checks whether the parameter value is a template which is evaluated against the fenced node
+func IsTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// Validate validates the syntax of the template, whose placeholders refer to the fenced node fields, e.g.
+// {{ .Node.Name }}, {{ .Node.ProviderID }}, {{ .Node.Labels["key"] }}, {{ .Node.Annotations["key"] }} or {{ .Node.Addresses["InternalIP"] }}
+func Validate(template string) error {
+	_, err := evaluate(template, nil)
+	return err
+}
+
+// Resolve evaluates the template against the node, and fails if a field which is referred by the template is missing
+func Resolve(template string, node *corev1.Node) (string, error) {
+	return evaluate(template, node)
+}
+
+// evaluate replaces the placeholders of the template with the node fields, or only validates the placeholders if the node is nil
+func evaluate(template string, node *corev1.Node) (string, error) {
+	var result strings.Builder
+	last := 0
+	for _, match := range placeholder.FindAllStringSubmatchIndex(template, -1) {
+		if err := validateText(template[last:match[0]]); err != nil {
+			return "", err
+		}
+		result.WriteString(template[last:match[0]])
+		value, err := evaluatePlaceholder(strings.TrimSpace(template[match[2]:match[3]]), node)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(value)
+		last = match[1]
+	}
+	if err := validateText(template[last:]); err != nil {
+		return "", err
+	}
+	result.WriteString(template[last:])
+	return result.String(), nil
+}
+
+// validateText checks that the text between the placeholders has no unmatched braces
+func validateText(text string) error {
+	if strings.Contains(text, "{{") || strings.Contains(text, "}}") {
+		return fmt.Errorf("unmatched braces in template text `%s`", text)
+	}
+	return nil
+}
+
+// evaluatePlaceholder returns the node field which is referred by the placeholder expression
+func evaluatePlaceholder(expression string, node *corev1.Node) (string, error) {
+	if match := scalarField.FindStringSubmatch(expression); match != nil {
+		if node == nil {
+			return "", nil
+		}
+		switch match[1] {
+		case fieldName:
+			return node.Name, nil
+		default:
+			if node.Spec.ProviderID == "" {
+				return "", fmt.Errorf("node %s has no provider ID", node.Name)
+			}
+			return node.Spec.ProviderID, nil
+		}
+	}
+
+	match := mapField.FindStringSubmatch(expression)
+	if match == nil {
+		return "", fmt.Errorf("unsupported template expression `%s`, expected .Node.%s, .Node.%s, or .Node.%s, .Node.%s or .Node.%s with a quoted key",
+			expression, fieldName, fieldProviderID, fieldLabels, fieldAnnotations, fieldAddresses)
+	}
+	if node == nil {
+		return "", nil
+	}
+	key := match[2]
+	switch match[1] {
+	case fieldLabels:
+		if value, exists := node.Labels[key]; exists {
+			return value, nil
+		}
+		return "", fmt.Errorf("node %s has no label %s", node.Name, key)
+	case fieldAnnotations:
+		if value, exists := node.Annotations[key]; exists {
+			return value, nil
+		}
+		return "", fmt.Errorf("node %s has no annotation %s", node.Name, key)
+	default:
+		for _, address := range node.Status.Addresses {
+			if string(address.Type) == key {
+				return address.Address, nil
+			}
+		}
+		return "", fmt.Errorf("node %s has no %s address", node.Name, key)
+	}
+}
@@ -0,0 +1,72 @@
+package nodetemplate
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "plainValue", template: "192.168.111.1", wantErr: false},
+		{name: "annotation", template: `{{ .Node.Annotations["bmc.example.com/address"] }}`, wantErr: false},
+		{name: "severalPlaceholders", template: `{{.Node.Labels["rack"]}}-{{ .Node.Name }}`, wantErr: false},
+		{name: "unsupportedField", template: `{{ .Node.Spec.PodCIDR }}`, wantErr: true},
+		{name: "unquotedKey", template: `{{ .Node.Labels[rack] }}`, wantErr: true},
+		{name: "unclosedPlaceholder", template: `{{ .Node.Name`, wantErr: true},
+		{name: "unopenedPlaceholder", template: `.Node.Name }}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.template); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "worker-0",
+			Labels:      map[string]string{"topology.kubernetes.io/zone": "rack-1"},
+			Annotations: map[string]string{"bmc.example.com/address": "192.168.111.1"},
+		},
+		Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeHostName, Address: "worker-0.example.com"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.10"},
+		}},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "plainValue", template: "6233", want: "6233"},
+		{name: "name", template: "{{ .Node.Name }}", want: "worker-0"},
+		{name: "providerID", template: "{{ .Node.ProviderID }}", want: "aws:///us-east-1a/i-0123"},
+		{name: "label", template: `outlet-{{ .Node.Labels["topology.kubernetes.io/zone"] }}`, want: "outlet-rack-1"},
+		{name: "annotation", template: `{{ .Node.Annotations["bmc.example.com/address"] }}`, want: "192.168.111.1"},
+		{name: "address", template: `{{ .Node.Addresses["InternalIP"] }}`, want: "10.0.0.10"},
+		{name: "missingAnnotation", template: `{{ .Node.Annotations["bmc.example.com/port"] }}`, wantErr: true},
+		{name: "missingAddress", template: `{{ .Node.Addresses["ExternalIP"] }}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.template, node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
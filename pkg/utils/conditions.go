@@ -15,6 +15,21 @@ import (
 const (
 	// FenceAgentActionSucceededType is the condition type used to signal whether the Fence Agent action was succeeded successfully or not
 	FenceAgentActionSucceededType = "FenceAgentActionSucceeded"
+	// FenceAgentVerifiedType is the condition type used to signal whether the node's power status was verified by the Fence Agent after a successful action
+	FenceAgentVerifiedType = "FenceAgentVerified"
+	// NodePoweredOnType is the condition type used to signal whether the node was powered on after it had been powered off by the Fence Agent
+	NodePoweredOnType = "NodePoweredOn"
+	// NodeRebootVerifiedType is the condition type used to signal whether the node was verified to have rebooted after it had been fenced
+	NodeRebootVerifiedType = "NodeRebootVerified"
+	// WaitingType is the condition type used to signal whether the node is waiting to be fenced, due to the cluster-wide fencing limits,
+	// to the etcd quorum protection, or to the manager running on the node
+	WaitingType = "Waiting"
+	// FencingReadyType is the condition type used to signal whether the fence agents of a FenceAgentsRemediationTemplate can reach the fencing devices of a node
+	FencingReadyType = "FencingReady"
+	// FencingDevicesReachableReason, FencingDevicesUnreachableReason and NoNodesCheckedReason are the reasons of the FencingReady condition
+	FencingDevicesReachableReason   = "FencingDevicesReachable"
+	FencingDevicesUnreachableReason = "FencingDevicesUnreachable"
+	NoNodesCheckedReason            = "NoNodesChecked"
 	// condition messages
 	RemediationFinishedNodeNotFoundConditionMessage = "FAR CR name doesn't match a node name"
 	RemediationInterruptedByNHCConditionMessage     = "Node Healthcheck timeout annotation has been set. Remediation has stopped"
@@ -22,7 +37,24 @@ const (
 	FenceAgentSucceededConditionMessage             = "FAR taint was added and the fence agent command has been created and executed successfully"
 	FenceAgentFailedConditionMessage                = "Fence agent command has failed"
 	FenceAgentTimedOutConditionMessage              = "Time out occurred while executing the Fence agent command"
+	FenceAgentVerificationSucceededConditionMessage = "The fence agent reported the expected power status of the node"
+	FenceAgentVerificationFailedConditionMessage    = "The fence agent didn't report the expected power status of the node within the verification timeout"
+	NodePowerOnStartedConditionMessage              = "The node is being powered on by the fence agent"
+	NodePowerOnSucceededConditionMessage            = "The node was powered on by the fence agent"
+	NodePowerOnFailedConditionMessage               = "The fence agent has failed to power on the node"
+	NodeRebootConfirmedConditionMessage             = "The node has rebooted after it was fenced, since its boot ID has changed or it has become ready again"
+	NodeRebootTimedOutConditionMessage              = "The node wasn't verified to have rebooted within the reboot verification timeout"
 	RemediationFinishedSuccessfullyConditionMessage = "The unhealthy node was fully remediated (it was tainted, fenced using the fence agent and all the node resources have been deleted)"
+	FencingConcurrencyLimitReachedConditionMessage  = "The node is waiting to be fenced, since the maximal number of nodes are already being fenced"
+	RemediationStormDetectedConditionMessage        = "The node is waiting to be fenced, since the number of unhealthy nodes is above the remediation storm threshold"
+	ControlPlaneQuorumProtectedConditionMessage     = "The control-plane node is waiting to be fenced, since fencing it would break the etcd quorum"
+	FencingHandedOffConditionMessage                = "The node is waiting to be fenced by a manager on another node, since the manager runs on the node"
+	FencingAdmittedConditionMessage                 = "The node is no longer waiting to be fenced"
+	FencingReadyConditionMessage                    = "The fence agents reported the power status of the node for all the fencing levels"
+	FencingNotReadyConditionMessage                 = "The fence agents couldn't report the power status of the node for some of the fencing levels"
+	AllNodesFencingReadyConditionMessage            = "The fence agents reported the power status of all the checked nodes"
+	NodesNotFencingReadyConditionMessage            = "The fence agents couldn't report the power status of %d of the %d checked nodes"
+	NoNodesFencingCheckedConditionMessage           = "No node was checked, since the fencing levels don't cover any node of the cluster"
 )
 
 // ConditionsChangeReason represents the reason of updating the some or all the conditions
@@ -41,8 +73,32 @@ const (
 	FenceAgentFailed ConditionsChangeReason = "FenceAgentFailed"
 	// FenceAgentTimedOut - Fence agent command has been created but timed out
 	FenceAgentTimedOut ConditionsChangeReason = "FenceAgentTimedOut"
+	// FenceAgentVerificationSucceeded - Fence agent status action reported the expected power status after a successful fence agent command
+	FenceAgentVerificationSucceeded ConditionsChangeReason = "FenceAgentVerificationSucceeded"
+	// FenceAgentVerificationFailed - Fence agent status action didn't report the expected power status within the verification timeout
+	FenceAgentVerificationFailed ConditionsChangeReason = "FenceAgentVerificationFailed"
+	// NodePowerOnStarted - Fence agent command which powers on the node has been created, after the node was powered off
+	NodePowerOnStarted ConditionsChangeReason = "NodePowerOnStarted"
+	// NodePowerOnSucceeded - Fence agent command which powers on the node has been executed successfully
+	NodePowerOnSucceeded ConditionsChangeReason = "NodePowerOnSucceeded"
+	// NodePowerOnFailed - Fence agent command which powers on the node has failed or timed out
+	NodePowerOnFailed ConditionsChangeReason = "NodePowerOnFailed"
+	// NodeRebootConfirmed - The node's boot ID has changed, or the node has become ready, after it was fenced
+	NodeRebootConfirmed ConditionsChangeReason = "NodeRebootConfirmed"
+	// NodeRebootTimedOut - The node wasn't verified to have rebooted within the reboot verification timeout
+	NodeRebootTimedOut ConditionsChangeReason = "NodeRebootTimedOut"
 	// RemediationFinishedSuccessfully - The unhealthy node was fully remediated/fenced (it was tainted, fenced by FA and all of its resources have been deleted)
 	RemediationFinishedSuccessfully ConditionsChangeReason = "RemediationFinishedSuccessfully"
+	// FencingConcurrencyLimitReached - The node isn't fenced yet, since the maximal number of nodes are already being fenced
+	FencingConcurrencyLimitReached ConditionsChangeReason = "FencingConcurrencyLimitReached"
+	// RemediationStormDetected - The node isn't fenced yet, since the number of unhealthy nodes is above the remediation storm threshold
+	RemediationStormDetected ConditionsChangeReason = "RemediationStormDetected"
+	// ControlPlaneQuorumProtected - The control-plane node isn't fenced yet, since too few of the other control-plane nodes are healthy to keep the etcd quorum
+	ControlPlaneQuorumProtected ConditionsChangeReason = "ControlPlaneQuorumProtected"
+	// FencingHandedOff - The node isn't fenced yet, since the manager runs on it, and it is handed off to a manager on another node
+	FencingHandedOff ConditionsChangeReason = "FencingHandedOff"
+	// FencingAdmitted - The node which was waiting to be fenced is fenced now
+	FencingAdmitted ConditionsChangeReason = "FencingAdmitted"
 )
 
 // updateConditions updates the status conditions of a FenceAgentsRemediation object based on the provided ConditionsChangeReason.
@@ -51,23 +107,46 @@ func UpdateConditions(reason ConditionsChangeReason, far *v1alpha1.FenceAgentsRe
 
 	var (
 		processingConditionStatus, fenceAgentActionSucceededConditionStatus, succeededConditionStatus metav1.ConditionStatus
+		fenceAgentVerifiedConditionStatus, nodePoweredOnConditionStatus, waitingConditionStatus       metav1.ConditionStatus
+		nodeRebootVerifiedConditionStatus                                                             metav1.ConditionStatus
 		conditionMessage                                                                              string
 	)
 	conditionUpdateMessage := "Couldn't update FAR Status Conditions"
 	unknownError := fmt.Errorf("unknown ConditionsChangeReason")
 	currentConditions := &far.Status.Conditions
 	conditionHasBeenChanged := false
+	isVerificationEnabled := far.Spec.Verification != nil
+	isRebootVerificationEnabled := far.Spec.RebootVerification != nil
 
 	// RemediationFinishedNodeNotFound and RemediationInterruptedByNHC reasons can happen at any time the Reconcile runs
 	// - Except these two reasons, the following reasons can only happen one after another
 	// - RemediationStarted will always be the first reason (out of these three)
 	// - FenceAgentSucceeded, FenceAgentFailed and FenceAgentTimedOut can only happen after RemediationStarted happened
-	// - RemediationFinishedSuccessfully can only happen after FenceAgentSucceeded happened
+	// - FenceAgentVerificationSucceeded and FenceAgentVerificationFailed can only happen after FenceAgentSucceeded happened, and only when verification is enabled
+	// - NodePowerOnStarted can only happen after FenceAgentSucceeded happened, and only when the fencing mode powers off the node
+	// - NodePowerOnSucceeded and NodePowerOnFailed can only happen after NodePowerOnStarted happened
+	// - RemediationFinishedSuccessfully can only happen after FenceAgentSucceeded happened, after FenceAgentVerificationSucceeded when verification is enabled,
+	//   after NodePowerOnSucceeded when the fencing mode is OffThenOn, and after NodeRebootConfirmed when reboot verification is enabled
+	// - NodeRebootConfirmed and NodeRebootTimedOut can only happen after FenceAgentSucceeded happened, and only when reboot verification is enabled
+	// - FencingConcurrencyLimitReached, RemediationStormDetected, ControlPlaneQuorumProtected and FencingHandedOff can only happen after
+	//   RemediationStarted happened, and before the fence agent is executed
+	// - FencingAdmitted can only happen after FencingConcurrencyLimitReached, RemediationStormDetected, ControlPlaneQuorumProtected or
+	//   FencingHandedOff happened
 	switch reason {
 	case RemediationFinishedNodeNotFound, RemediationInterruptedByNHC, FenceAgentFailed, FenceAgentTimedOut:
 		processingConditionStatus = metav1.ConditionFalse
 		fenceAgentActionSucceededConditionStatus = metav1.ConditionFalse
 		succeededConditionStatus = metav1.ConditionFalse
+		if isVerificationEnabled {
+			fenceAgentVerifiedConditionStatus = metav1.ConditionFalse
+		}
+		if isRebootVerificationEnabled {
+			nodeRebootVerifiedConditionStatus = metav1.ConditionFalse
+		}
+		// a remediation which ends while the node is waiting to be fenced isn't waiting anymore
+		if meta.IsStatusConditionTrue(*currentConditions, WaitingType) {
+			waitingConditionStatus = metav1.ConditionFalse
+		}
 		// Different reasons share the same effect to the conditions, but they have different message
 		switch reason {
 		case RemediationFinishedNodeNotFound:
@@ -87,11 +166,75 @@ func UpdateConditions(reason ConditionsChangeReason, far *v1alpha1.FenceAgentsRe
 		processingConditionStatus = metav1.ConditionTrue
 		fenceAgentActionSucceededConditionStatus = metav1.ConditionUnknown
 		succeededConditionStatus = metav1.ConditionUnknown
+		if isVerificationEnabled {
+			fenceAgentVerifiedConditionStatus = metav1.ConditionUnknown
+		}
+		if isRebootVerificationEnabled {
+			nodeRebootVerifiedConditionStatus = metav1.ConditionUnknown
+		}
 		conditionMessage = RemediationStartedConditionMessage
 	case FenceAgentSucceeded:
 		fenceAgentActionSucceededConditionStatus = metav1.ConditionTrue
 		conditionMessage = FenceAgentSucceededConditionMessage
+	case FenceAgentVerificationSucceeded:
+		fenceAgentVerifiedConditionStatus = metav1.ConditionTrue
+		conditionMessage = FenceAgentVerificationSucceededConditionMessage
+	case FenceAgentVerificationFailed:
+		// The fence agent action itself has succeeded, thus FenceAgentActionSucceeded remains as is
+		processingConditionStatus = metav1.ConditionFalse
+		fenceAgentVerifiedConditionStatus = metav1.ConditionFalse
+		succeededConditionStatus = metav1.ConditionFalse
+		conditionMessage = FenceAgentVerificationFailedConditionMessage
+	case NodePowerOnStarted:
+		nodePoweredOnConditionStatus = metav1.ConditionUnknown
+		conditionMessage = NodePowerOnStartedConditionMessage
+	case NodePowerOnSucceeded:
+		nodePoweredOnConditionStatus = metav1.ConditionTrue
+		conditionMessage = NodePowerOnSucceededConditionMessage
+	case NodePowerOnFailed:
+		nodePoweredOnConditionStatus = metav1.ConditionFalse
+		// A node which fails to be powered on after a successful remediation, e.g. on CR deletion in OffOnly fencing mode, doesn't fail the remediation
+		if !meta.IsStatusConditionTrue(*currentConditions, commonConditions.SucceededType) {
+			processingConditionStatus = metav1.ConditionFalse
+			succeededConditionStatus = metav1.ConditionFalse
+		}
+		conditionMessage = NodePowerOnFailedConditionMessage
+	case NodeRebootConfirmed:
+		nodeRebootVerifiedConditionStatus = metav1.ConditionTrue
+		conditionMessage = NodeRebootConfirmedConditionMessage
+	case NodeRebootTimedOut:
+		processingConditionStatus = metav1.ConditionFalse
+		nodeRebootVerifiedConditionStatus = metav1.ConditionFalse
+		succeededConditionStatus = metav1.ConditionFalse
+		conditionMessage = NodeRebootTimedOutConditionMessage
+	case FencingConcurrencyLimitReached:
+		waitingConditionStatus = metav1.ConditionTrue
+		conditionMessage = FencingConcurrencyLimitReachedConditionMessage
+	case RemediationStormDetected:
+		waitingConditionStatus = metav1.ConditionTrue
+		conditionMessage = RemediationStormDetectedConditionMessage
+	case ControlPlaneQuorumProtected:
+		waitingConditionStatus = metav1.ConditionTrue
+		conditionMessage = ControlPlaneQuorumProtectedConditionMessage
+	case FencingHandedOff:
+		waitingConditionStatus = metav1.ConditionTrue
+		conditionMessage = FencingHandedOffConditionMessage
+	case FencingAdmitted:
+		waitingConditionStatus = metav1.ConditionFalse
+		conditionMessage = FencingAdmittedConditionMessage
 	case RemediationFinishedSuccessfully:
+		if isVerificationEnabled && !meta.IsStatusConditionTrue(*currentConditions, FenceAgentVerifiedType) {
+			log.Error(fmt.Errorf("power status wasn't verified"), conditionUpdateMessage, "CR name", far.Name, "Reason", reason)
+			return
+		}
+		if far.Spec.FencingMode == v1alpha1.OffThenOnFencingMode && !meta.IsStatusConditionTrue(*currentConditions, NodePoweredOnType) {
+			log.Error(fmt.Errorf("node wasn't powered on"), conditionUpdateMessage, "CR name", far.Name, "Reason", reason)
+			return
+		}
+		if isRebootVerificationEnabled && !meta.IsStatusConditionTrue(*currentConditions, NodeRebootVerifiedType) {
+			log.Error(fmt.Errorf("node reboot wasn't verified"), conditionUpdateMessage, "CR name", far.Name, "Reason", reason)
+			return
+		}
 		processingConditionStatus = metav1.ConditionFalse
 		succeededConditionStatus = metav1.ConditionTrue
 		conditionMessage = RemediationFinishedSuccessfullyConditionMessage
@@ -122,6 +265,52 @@ func UpdateConditions(reason ConditionsChangeReason, far *v1alpha1.FenceAgentsRe
 		conditionHasBeenChanged = true
 	}
 
+	// if the requested Status.Conditions.FenceAgentVerified is different then the current one, then update Status.Conditions.FenceAgentVerified value
+	if fenceAgentVerifiedConditionStatus != "" && !meta.IsStatusConditionPresentAndEqual(*currentConditions, FenceAgentVerifiedType, fenceAgentVerifiedConditionStatus) {
+		meta.SetStatusCondition(currentConditions, metav1.Condition{
+			Type:    FenceAgentVerifiedType,
+			Status:  fenceAgentVerifiedConditionStatus,
+			Reason:  string(reason),
+			Message: conditionMessage,
+		})
+		conditionHasBeenChanged = true
+	}
+
+	// if the requested Status.Conditions.NodePoweredOn is different then the current one, then update Status.Conditions.NodePoweredOn value
+	if nodePoweredOnConditionStatus != "" && !meta.IsStatusConditionPresentAndEqual(*currentConditions, NodePoweredOnType, nodePoweredOnConditionStatus) {
+		meta.SetStatusCondition(currentConditions, metav1.Condition{
+			Type:    NodePoweredOnType,
+			Status:  nodePoweredOnConditionStatus,
+			Reason:  string(reason),
+			Message: conditionMessage,
+		})
+		conditionHasBeenChanged = true
+	}
+
+	// if the requested Status.Conditions.NodeRebootVerified is different then the current one, then update Status.Conditions.NodeRebootVerified value
+	if nodeRebootVerifiedConditionStatus != "" && !meta.IsStatusConditionPresentAndEqual(*currentConditions, NodeRebootVerifiedType, nodeRebootVerifiedConditionStatus) {
+		meta.SetStatusCondition(currentConditions, metav1.Condition{
+			Type:    NodeRebootVerifiedType,
+			Status:  nodeRebootVerifiedConditionStatus,
+			Reason:  string(reason),
+			Message: conditionMessage,
+		})
+		conditionHasBeenChanged = true
+	}
+
+	// if the requested Status.Conditions.Waiting is different then the current one, then update Status.Conditions.Waiting value.
+	// The reason is compared as well, since the node can wait for different reasons
+	if waitingCondition := meta.FindStatusCondition(*currentConditions, WaitingType); waitingConditionStatus != "" &&
+		(waitingCondition == nil || waitingCondition.Status != waitingConditionStatus || waitingCondition.Reason != string(reason)) {
+		meta.SetStatusCondition(currentConditions, metav1.Condition{
+			Type:    WaitingType,
+			Status:  waitingConditionStatus,
+			Reason:  string(reason),
+			Message: conditionMessage,
+		})
+		conditionHasBeenChanged = true
+	}
+
 	// if the requested Status.Conditions.Succeeded is different then the current one, then update Status.Conditions.Succeeded value
 	if succeededConditionStatus != "" && !meta.IsStatusConditionPresentAndEqual(*currentConditions, commonConditions.SucceededType, succeededConditionStatus) {
 		meta.SetStatusCondition(currentConditions, metav1.Condition{
@@ -137,7 +326,7 @@ func UpdateConditions(reason ConditionsChangeReason, far *v1alpha1.FenceAgentsRe
 		now := metav1.Now()
 		far.Status.LastUpdateTime = &now
 	}
-	log.Info("Updating Status Condition", "processingConditionStatus", processingConditionStatus, "fenceAgentActionSucceededConditionStatus", fenceAgentActionSucceededConditionStatus, "succeededConditionStatus", succeededConditionStatus, "reason", string(reason), "LastUpdateTime", far.Status.LastUpdateTime.Time)
+	log.Info("Updating Status Condition", "processingConditionStatus", processingConditionStatus, "fenceAgentActionSucceededConditionStatus", fenceAgentActionSucceededConditionStatus, "fenceAgentVerifiedConditionStatus", fenceAgentVerifiedConditionStatus, "nodePoweredOnConditionStatus", nodePoweredOnConditionStatus, "nodeRebootVerifiedConditionStatus", nodeRebootVerifiedConditionStatus, "waitingConditionStatus", waitingConditionStatus, "succeededConditionStatus", succeededConditionStatus, "reason", string(reason), "LastUpdateTime", far.Status.LastUpdateTime.Time)
 
 	return
 }
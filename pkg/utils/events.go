@@ -14,10 +14,31 @@ const (
 	EventReasonAddRemediationTaint      = "AddRemediationTaint"
 	EventReasonFenceAgentExecuted       = "FenceAgentExecuted"
 	EventReasonFenceAgentSucceeded      = "FenceAgentSucceeded"
+	EventReasonFenceAgentVerified       = "FenceAgentVerified"
+	EventReasonFenceAgentNotVerified    = "FenceAgentNotVerified"
+	EventReasonPowerOnNode              = "PowerOnNode"
+	EventReasonNodePoweredOn            = "NodePoweredOn"
+	EventReasonNodePowerOnFailed        = "NodePowerOnFailed"
 	EventReasonDeleteResources          = "DeleteResources"
 	EventReasonAddOutOfServiceTaint     = "AddOutOfServiceTaint"
 	EventReasonRemoveOutOfServiceTaint  = "RemoveOutOfServiceTaint"
 	EventReasonNodeRemediationCompleted = "NodeRemediationCompleted"
+	EventReasonFencingTestCompleted     = "FencingTestCompleted"
+	EventReasonFencingTestUnreachable   = "FencingTestUnreachable"
+	EventReasonNodeFencingNotReady      = "NodeFencingNotReady"
+	EventReasonFenceAgentResumed        = "FenceAgentResumed"
+	EventReasonFencingQueued            = "FencingQueued"
+	EventReasonRemediationStorm         = "RemediationStorm"
+	EventReasonQuorumProtected          = "QuorumProtected"
+	EventReasonQuorumLossAllowed        = "QuorumLossAllowed"
+	EventReasonFencingHandedOff         = "FencingHandedOff"
+	EventReasonFencingHandOffTimedOut   = "FencingHandOffTimedOut"
+	EventReasonNodeRebootVerified       = "NodeRebootVerified"
+	EventReasonNodeRebootNotVerified    = "NodeRebootNotVerified"
+	EventReasonRemediationStrategy      = "RemediationStrategySelected"
+	EventReasonDeleteVolumeAttachment   = "DeleteVolumeAttachment"
+	EventReasonInvalidFencingLevel      = "InvalidFencingLevel"
+	EventReasonPartialFencing           = "PartialFencing"
 
 	// events messages
 	EventMessageCrNodeNotFound           = "CR name doesn't match a node name"
@@ -28,8 +49,30 @@ const (
 	EventMessageAddRemediationTaint      = "Remediation taint was added"
 	EventMessageFenceAgentExecuted       = "Fence agent was executed"
 	EventMessageFenceAgentSucceeded      = "Fence agent was succeeded"
+	EventMessageFenceAgentVerified       = "Fence agent verified the expected power status of the node"
+	EventMessageFenceAgentNotVerified    = "Fence agent couldn't verify the expected power status of the node"
+	EventMessagePowerOnNode              = "Fence agent was executed to power on the node"
+	EventMessageNodePoweredOn            = "The node was powered on by the fence agent"
+	EventMessageNodePowerOnFailed        = "Fence agent has failed to power on the node"
 	EventMessageDeleteResources          = "Manually delete pods from the unhealthy node"
 	EventMessageAddOutOfServiceTaint     = "The out-of-service taint was added"
 	EventMessageRemoveOutOfServiceTaint  = "The out-of-service taint was removed"
 	EventMessageNodeRemediationCompleted = "Unhealthy node remediation was completed"
+	EventMessageFencingTestCompleted     = "Fence agent reported the power status of all the tested nodes"
+	EventMessageFencingTestUnreachable   = "Fence agent couldn't report the power status of some of the tested nodes"
+	EventMessageNodeFencingNotReady      = "Fence agent couldn't report the power status of node %s"
+	EventMessageFenceAgentResumed        = "Fence agent execution of a previous manager was resumed at fencing level %d, attempt %d"
+	EventMessageFencingQueued            = "Node fencing is queued, since %d nodes are already being fenced, and at most %d nodes can be fenced at the same time"
+	EventMessageRemediationStorm         = "Node fencing is paused, since %d of the %d nodes are unhealthy, which is above the remediation storm threshold of %d nodes"
+	EventMessageQuorumProtected          = "Control-plane node fencing is paused, since only %d of the other %d control-plane nodes are healthy, and the etcd quorum requires %d nodes"
+	EventMessageQuorumLossAllowed        = "Control-plane node is fenced although it might break the etcd quorum, since the allow-quorum-loss annotation is set"
+	EventMessageFencingHandedOff         = "Node fencing is handed off to a manager on another node, since the manager pod %s runs on the node"
+	EventMessageFencingHandOffTimedOut   = "The manager pod %s still runs on node %s %s after the node fencing was handed off, thus it is deleted again"
+	EventMessageNodeRebootVerified       = "The node has rebooted after it was fenced"
+	EventMessageNodeRebootNotVerified    = "The node wasn't verified to have rebooted within the reboot verification timeout"
+	EventMessageRemediationStrategy      = "The %s remediation strategy was selected automatically"
+	EventMessageDeleteVolumeAttachment   = "The volume attachment %s of persistent volume %s was deleted from the unhealthy node"
+	EventMessageInvalidFencingLevel      = "Fence agent commands of fencing level %d couldn't be built: %v"
+	EventMessagePartialFencing           = "Fencing level %d failed at device %d, while %d of its devices which were already powered off remain powered off"
+	EventMessagePartialFencingRestored   = "Fencing level %d failed at device %d, thus %d of its devices which were already powered off were powered on again"
 )
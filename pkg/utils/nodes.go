@@ -26,6 +26,23 @@ func GetNodeWithName(r client.Reader, nodeName string) (*corev1.Node, error) {
 	return node, nil
 }
 
+// IsControlPlaneNode checks whether the node has one of the control-plane role labels
+func IsControlPlaneNode(node *corev1.Node) bool {
+	_, isControlPlane := node.Labels[medik8sLabels.ControlPlaneRole]
+	_, isMaster := node.Labels[medik8sLabels.MasterRole]
+	return isControlPlane || isMaster
+}
+
+// IsNodeReady checks whether the node's Ready condition is true
+func IsNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // GetNode returns a node object with the name nodeName based on the nodeType input
 // used for making new node object for test and have a unique resourceVersion
 func GetNode(nodeRole, nodeName string) *corev1.Node {
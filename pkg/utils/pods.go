@@ -3,11 +3,15 @@ package utils
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
 )
 
 // GetFenceAgentsRemediationPod fetches the first running pod that matches to FAR's label and namespace
@@ -39,3 +43,67 @@ func GetFenceAgentsRemediationPod(r client.Reader) (*corev1.Pod, error) {
 	}
 	return nil, fmt.Errorf("no running FAR pods were found")
 }
+
+// GetNodePods returns the pods which are scheduled on the node
+func GetNodePods(ctx context.Context, r client.Reader, nodeName string) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList); err != nil {
+		return nil, fmt.Errorf("failed fetching pods - %w", err)
+	}
+	var nodePods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == nodeName {
+			nodePods = append(nodePods, pod)
+		}
+	}
+	return nodePods, nil
+}
+
+// SelectPods splits the pods into the pods which are selected for deletion by the pod selection, and the pods which are skipped
+func SelectPods(pods []corev1.Pod, podSelection *v1alpha1.PodSelection) ([]corev1.Pod, []corev1.Pod, error) {
+	if podSelection == nil {
+		return pods, nil, nil
+	}
+	includeSelector := labels.Everything()
+	if podSelection.IncludeSelector != nil {
+		var err error
+		if includeSelector, err = metav1.LabelSelectorAsSelector(podSelection.IncludeSelector); err != nil {
+			return nil, nil, fmt.Errorf("invalid include selector - %w", err)
+		}
+	}
+	excludeSelector := labels.Nothing()
+	if podSelection.ExcludeSelector != nil {
+		var err error
+		if excludeSelector, err = metav1.LabelSelectorAsSelector(podSelection.ExcludeSelector); err != nil {
+			return nil, nil, fmt.Errorf("invalid exclude selector - %w", err)
+		}
+	}
+
+	var selectedPods, skippedPods []corev1.Pod
+	for _, pod := range pods {
+		podLabels := labels.Set(pod.Labels)
+		switch {
+		case slices.Contains(podSelection.ExcludedNamespaces, pod.Namespace),
+			!includeSelector.Matches(podLabels),
+			excludeSelector.Matches(podLabels),
+			podSelection.SkipDaemonSetPods && isDaemonSetPod(&pod),
+			podSelection.SkipStaticPods && isStaticPod(&pod):
+			skippedPods = append(skippedPods, pod)
+		default:
+			selectedPods = append(selectedPods, pod)
+		}
+	}
+	return selectedPods, skippedPods, nil
+}
+
+// isDaemonSetPod checks whether the pod is controlled by a DaemonSet
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// isStaticPod checks whether the pod is the mirror pod of a static pod
+func isStaticPod(pod *corev1.Pod) bool {
+	_, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return isMirror
+}
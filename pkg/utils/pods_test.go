@@ -0,0 +1,63 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/medik8s/fence-agents-remediation/api/v1alpha1"
+)
+
+var _ = Describe("Utils-pods", func() {
+	Context("Pod selection", func() {
+		buildPod := func(name, namespace string, podLabels map[string]string) corev1.Pod {
+			return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: podLabels}}
+		}
+		appPod := buildPod("app", "default", map[string]string{"app": "web"})
+		dbPod := buildPod("db", "default", map[string]string{"app": "db"})
+		operatorPod := buildPod("operator", "operators", nil)
+		daemonSetPod := buildPod("daemonset", "default", nil)
+		isController := true
+		daemonSetPod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "ds", Controller: &isController}}
+		staticPod := buildPod("static", "kube-system", nil)
+		staticPod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+		pods := []corev1.Pod{appPod, dbPod, operatorPod, daemonSetPod, staticPod}
+
+		podNames := func(pods []corev1.Pod) []string {
+			names := []string{}
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			return names
+		}
+
+		DescribeTable("should split the pods into selected and skipped pods",
+			func(podSelection *v1alpha1.PodSelection, expectedSelected, expectedSkipped []string) {
+				selectedPods, skippedPods, err := SelectPods(pods, podSelection)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(podNames(selectedPods)).To(ConsistOf(expectedSelected))
+				Expect(podNames(skippedPods)).To(ConsistOf(expectedSkipped))
+			},
+			Entry("without a pod selection", nil,
+				[]string{"app", "db", "operator", "daemonset", "static"}, []string{}),
+			Entry("with excluded namespaces", &v1alpha1.PodSelection{ExcludedNamespaces: []string{"operators", "kube-system"}},
+				[]string{"app", "db", "daemonset"}, []string{"operator", "static"}),
+			Entry("with an include selector", &v1alpha1.PodSelection{IncludeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+				[]string{"app"}, []string{"db", "operator", "daemonset", "static"}),
+			Entry("with an exclude selector", &v1alpha1.PodSelection{ExcludeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+				[]string{"db", "operator", "daemonset", "static"}, []string{"app"}),
+			Entry("with DaemonSet and static pods skipped", &v1alpha1.PodSelection{SkipDaemonSetPods: true, SkipStaticPods: true},
+				[]string{"app", "db", "operator"}, []string{"daemonset", "static"}),
+		)
+
+		It("should fail with an invalid selector", func() {
+			podSelection := &v1alpha1.PodSelection{ExcludeSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}},
+			}}
+			_, _, err := SelectPods(pods, podSelection)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
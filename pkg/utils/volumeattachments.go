@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetNodeVolumeAttachments returns the volume attachments which attach volumes to the node
+func GetNodeVolumeAttachments(ctx context.Context, r client.Reader, nodeName string) ([]storagev1.VolumeAttachment, error) {
+	volumeAttachmentList := &storagev1.VolumeAttachmentList{}
+	if err := r.List(ctx, volumeAttachmentList); err != nil {
+		return nil, fmt.Errorf("failed fetching volume attachments - %w", err)
+	}
+	var nodeVolumeAttachments []storagev1.VolumeAttachment
+	for _, volumeAttachment := range volumeAttachmentList.Items {
+		if volumeAttachment.Spec.NodeName == nodeName {
+			nodeVolumeAttachments = append(nodeVolumeAttachments, volumeAttachment)
+		}
+	}
+	return nodeVolumeAttachments, nil
+}
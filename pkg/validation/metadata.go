@@ -0,0 +1,262 @@
+package validation
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// agentMetadataTimeout is the timeout for getting the fence agent metadata
+	agentMetadataTimeout = 10 * time.Second
+
+	// fence agent parameter content types
+	parameterTypeBoolean = "boolean"
+	parameterTypeInteger = "integer"
+	parameterTypeSecond  = "second"
+	parameterTypeSelect  = "select"
+)
+
+var (
+	// longOptionRegex matches the long options of a fence agent parameter in its metadata, e.g. "--username" in "-l, --username=[name]"
+	longOptionRegex = regexp.MustCompile(`--[a-zA-Z0-9_-]+`)
+	// shortOptionRegex matches the short option of a fence agent parameter in its metadata, e.g. "-l" in "-l, --username=[name]"
+	shortOptionRegex = regexp.MustCompile(`(?:^|[\s,])(-[a-zA-Z0-9])\b`)
+)
+
+// AgentMetadataGetter returns the metadata XML of the fence agent
+type AgentMetadataGetter func(agent string) ([]byte, error)
+
+// AgentParameter is a fence agent parameter as described by the agent metadata
+type AgentParameter struct {
+	// Name is the parameter name, which is also its name on stdin
+	Name string
+	// Options are the command line options of the parameter, e.g. "--username" and "-l"
+	Options []string
+	// Type is the parameter content type, e.g. "string", "boolean", "integer", "second" or "select"
+	Type string
+	// Required is true if the agent can't run without the parameter
+	Required bool
+	// Default is the parameter default value, if any
+	Default string
+	// AllowedValues are the values of a "select" parameter
+	AllowedValues []string
+	// Deprecated is true if the parameter was replaced by another parameter
+	Deprecated bool
+	// Obsoletes is the name of the deprecated parameter which this parameter replaces, if any
+	Obsoletes string
+	// Description is the parameter short description
+	Description string
+}
+
+// AgentSchema is the parameters schema of a fence agent
+type AgentSchema struct {
+	// Description is the fence agent short description
+	Description string
+	// Actions are the actions which the fence agent supports, e.g. "on", "off" and "reboot"
+	Actions    []string
+	Parameters []AgentParameter
+	// lookup maps the parameters' names and options to the parameters
+	lookup map[string]*AgentParameter
+}
+
+// agentMetadata is the fence agent metadata XML, e.g. the output of `fence_ipmilan -o metadata`
+type agentMetadata struct {
+	ShortDesc  string `xml:"shortdesc,attr"`
+	Parameters []struct {
+		Name       string `xml:"name,attr"`
+		Required   string `xml:"required,attr"`
+		Deprecated string `xml:"deprecated,attr"`
+		Obsoletes  string `xml:"obsoletes,attr"`
+		Getopt     struct {
+			Mixed string `xml:"mixed,attr"`
+		} `xml:"getopt"`
+		Content struct {
+			Type    string `xml:"type,attr"`
+			Default string `xml:"default,attr"`
+			Options []struct {
+				Value string `xml:"value,attr"`
+			} `xml:"option"`
+		} `xml:"content"`
+		ShortDesc []struct {
+			Lang  string `xml:"lang,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"shortdesc"`
+	} `xml:"parameters>parameter"`
+	Actions []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"actions>action"`
+}
+
+// ParseAgentMetadata parses the fence agent metadata XML to the agent parameters schema
+func ParseAgentMetadata(metadata []byte) (*AgentSchema, error) {
+	parsedMetadata := agentMetadata{}
+	if err := xml.Unmarshal(metadata, &parsedMetadata); err != nil {
+		return nil, err
+	}
+
+	var actions []string
+	for _, action := range parsedMetadata.Actions {
+		actions = append(actions, action.Name)
+	}
+	var parameters []AgentParameter
+	for _, parameter := range parsedMetadata.Parameters {
+		agentParameter := AgentParameter{
+			Name:       parameter.Name,
+			Type:       parameter.Content.Type,
+			Required:   parameter.Required == "1",
+			Default:    parameter.Content.Default,
+			Deprecated: parameter.Deprecated == "1",
+			Obsoletes:  parameter.Obsoletes,
+		}
+		agentParameter.Options = append(agentParameter.Options, longOptionRegex.FindAllString(parameter.Getopt.Mixed, -1)...)
+		for _, match := range shortOptionRegex.FindAllStringSubmatch(parameter.Getopt.Mixed, -1) {
+			agentParameter.Options = append(agentParameter.Options, match[1])
+		}
+		for _, option := range parameter.Content.Options {
+			agentParameter.AllowedValues = append(agentParameter.AllowedValues, option.Value)
+		}
+		for _, shortDesc := range parameter.ShortDesc {
+			// prefer the English description, which is the only one most agents have
+			if agentParameter.Description == "" || shortDesc.Lang == "en" {
+				agentParameter.Description = strings.TrimSpace(shortDesc.Value)
+			}
+		}
+		parameters = append(parameters, agentParameter)
+	}
+	return NewAgentSchema(parsedMetadata.ShortDesc, actions, parameters), nil
+}
+
+// NewAgentSchema returns the schema of a fence agent with the given description, actions and parameters
+func NewAgentSchema(description string, actions []string, parameters []AgentParameter) *AgentSchema {
+	schema := &AgentSchema{Description: description, Actions: actions, Parameters: parameters, lookup: make(map[string]*AgentParameter)}
+	// deprecated parameters share their options with the parameters which replace them, thus the latter take precedence
+	for i := range schema.Parameters {
+		parameter := &schema.Parameters[i]
+		if _, exist := schema.lookup[parameter.Name]; !exist || !parameter.Deprecated {
+			schema.lookup[parameter.Name] = parameter
+		}
+		for _, option := range parameter.Options {
+			if _, exist := schema.lookup[option]; !exist || !parameter.Deprecated {
+				schema.lookup[option] = parameter
+			}
+		}
+	}
+	return schema
+}
+
+// Lookup returns the agent parameter matching the given command line option or stdin name, if any
+func (s *AgentSchema) Lookup(option string) (*AgentParameter, bool) {
+	parameter, isFound := s.lookup[option]
+	return parameter, isFound
+}
+
+// StdinNames maps the long options of the parameters to their stdin names
+func (s *AgentSchema) StdinNames() map[string]string {
+	stdinNames := make(map[string]string)
+	for option, parameter := range s.lookup {
+		if strings.HasPrefix(option, "--") {
+			stdinNames[strings.TrimPrefix(option, "--")] = parameter.Name
+		}
+	}
+	return stdinNames
+}
+
+// ValidateParameters validates the parameters and their values against the schema.
+// It returns an error for unknown parameters and invalid values, and warnings for missing required parameters.
+func (s *AgentSchema) ValidateParameters(params map[string][]string) ([]string, error) {
+	var errs []error
+	var warnings []string
+	provided := make(map[string]bool)
+	for _, paramName := range sortedKeys(params) {
+		parameter, isFound := s.Lookup(paramName)
+		if !isFound {
+			errs = append(errs, fmt.Errorf("unknown parameter %s", paramName))
+			continue
+		}
+		provided[parameter.Name] = true
+		if parameter.Deprecated {
+			warnings = append(warnings, fmt.Sprintf("parameter %s is deprecated", paramName))
+		}
+		for _, value := range params[paramName] {
+			if err := parameter.validateValue(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value of parameter %s: %w", paramName, err))
+			}
+		}
+	}
+
+	for _, parameter := range s.Parameters {
+		isProvided := provided[parameter.Name] || (parameter.Obsoletes != "" && provided[parameter.Obsoletes])
+		if parameter.Required && !parameter.Deprecated && parameter.Default == "" && !isProvided {
+			warnings = append(warnings, fmt.Sprintf("required parameter %s is missing", parameter.Name))
+		}
+	}
+	return warnings, errors.NewAggregate(errs)
+}
+
+// validateValue validates the value matches the parameter type. The errors don't include the value, since it might come from a Secret.
+func (p *AgentParameter) validateValue(value string) error {
+	switch p.Type {
+	case parameterTypeBoolean:
+		// boolean parameters are flags, but their value can be set explicitly on stdin
+		if value != "" && !slices.Contains([]string{"0", "1", "true", "false", "yes", "no", "on", "off"}, strings.ToLower(value)) {
+			return fmt.Errorf("the value is not a boolean")
+		}
+	case parameterTypeInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("the value is not an integer")
+		}
+	case parameterTypeSecond:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("the value is not a number of seconds")
+		}
+	case parameterTypeSelect:
+		if len(p.AllowedValues) > 0 && !slices.Contains(p.AllowedValues, value) {
+			return fmt.Errorf("the value is not one of the allowed values %v", p.AllowedValues)
+		}
+	}
+	return nil
+}
+
+// ListAgents returns the names of the fence agents which are installed in the agents directory
+func ListAgents() ([]string, error) {
+	agentPaths, err := filepath.Glob(filepath.Join(agentsDirectory, "fence_*"))
+	if err != nil {
+		return nil, err
+	}
+	agents := make([]string, 0, len(agentPaths))
+	for _, agentPath := range agentPaths {
+		agents = append(agents, filepath.Base(agentPath))
+	}
+	return agents, nil
+}
+
+// GetAgentMetadata runs the fence agent with the metadata action, and returns its output
+func GetAgentMetadata(agent string) ([]byte, error) {
+	// the agent name comes from the CR, hence it must not run a binary outside the agents directory
+	if !isAgentNameValid(agent) {
+		return nil, fmt.Errorf("invalid fence agent name %q", agent)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), agentMetadataTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, filepath.Join(agentsDirectory, agent), "-o", "metadata").Output()
+}
+
+func sortedKeys(params map[string][]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
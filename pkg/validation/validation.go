@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
@@ -19,6 +20,9 @@ var (
 )
 
 const (
+	// agentsDirectory is the directory of the fence agents binaries
+	agentsDirectory = "/usr/sbin/"
+
 	//out of service taint strategy const (supported from 1.26)
 	minK8sMajorVersionOutOfServiceTaint = 1
 	minK8sMinorVersionOutOfServiceTaint = 26
@@ -31,13 +35,24 @@ type OutOfServiceTaintValidator struct {
 type AgentExists func(string) (bool, error)
 type validateAgentExistence struct {
 	agentExists AgentExists
+	getMetadata AgentMetadataGetter
+	// schemas caches the parsed agents metadata, since the metadata of an agent doesn't change
+	schemas     map[string]*AgentSchema
+	schemasLock sync.Mutex
+}
+
+// isAgentNameValid returns true if the agent name is a file name, which doesn't lead outside the agents directory
+func isAgentNameValid(agent string) bool {
+	return agent != "" && filepath.Base(agent) == agent
 }
 
 // isAgentFileExists returns true if the agent name matches a binary, and false otherwise
 func isAgentFileExists(agent string) (bool, error) {
-	directory := "/usr/sbin/"
+	if !isAgentNameValid(agent) {
+		return false, nil
+	}
 	// Create the full path by joining the directory and filename
-	fullPath := filepath.Join(directory, agent)
+	fullPath := filepath.Join(agentsDirectory, agent)
 
 	// Check if the file exists
 	_, err := os.Stat(fullPath)
@@ -52,20 +67,53 @@ func isAgentFileExists(agent string) (bool, error) {
 
 type AgentValidator interface {
 	ValidateAgentName(agent string) (bool, error)
+	// ValidateAgentParameters validates the parameter names, mapped to all of their values, against the agent metadata,
+	// and returns warnings for issues which don't necessarily fail the agent
+	ValidateAgentParameters(agent string, params map[string][]string) ([]string, error)
 }
 
 func NewAgentValidator() AgentValidator {
-	return &validateAgentExistence{agentExists: isAgentFileExists}
+	return NewCustomAgentValidator(isAgentFileExists, GetAgentMetadata)
 }
 
-func NewCustomAgentValidator(agentExists AgentExists) AgentValidator {
-	return &validateAgentExistence{agentExists: agentExists}
+func NewCustomAgentValidator(agentExists AgentExists, getMetadata AgentMetadataGetter) AgentValidator {
+	return &validateAgentExistence{agentExists: agentExists, getMetadata: getMetadata, schemas: make(map[string]*AgentSchema)}
 }
 
 func (vfe *validateAgentExistence) ValidateAgentName(agent string) (bool, error) {
 	return vfe.agentExists(agent)
 }
 
+func (vfe *validateAgentExistence) ValidateAgentParameters(agent string, params map[string][]string) ([]string, error) {
+	schema, err := vfe.getAgentSchema(agent)
+	if err != nil {
+		// the parameters can't be validated, but the agent might still be able to run
+		loggerValidation.Error(err, "couldn't get the fence agent parameters schema", "agent", agent)
+		return []string{fmt.Sprintf("parameters of fence agent %s weren't validated, since its metadata is unavailable", agent)}, nil
+	}
+	return schema.ValidateParameters(params)
+}
+
+// getAgentSchema returns the cached parameters schema of the agent, or parses it from the agent metadata
+func (vfe *validateAgentExistence) getAgentSchema(agent string) (*AgentSchema, error) {
+	vfe.schemasLock.Lock()
+	defer vfe.schemasLock.Unlock()
+	if schema, exist := vfe.schemas[agent]; exist {
+		return schema, nil
+	}
+
+	metadata, err := vfe.getMetadata(agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the metadata of fence agent %s: %w", agent, err)
+	}
+	schema, err := ParseAgentMetadata(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the metadata of fence agent %s: %w", agent, err)
+	}
+	vfe.schemas[agent] = schema
+	return schema, nil
+}
+
 // NewOutOfServiceTaintValidator returns a validator to check if out-of-service taint
 // is supporetd on the cluster
 func NewOutOfServiceTaintValidator(config *rest.Config) (*OutOfServiceTaintValidator, error) {
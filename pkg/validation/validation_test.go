@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/version"
@@ -37,3 +38,96 @@ func Test_setOutOfTaintSupportedFlag(t *testing.T) {
 		})
 	}
 }
+
+const testAgentMetadata = `<?xml version="1.0" ?>
+<resource-agent name="fence_ipmilan" shortdesc="Fence agent for IPMI">
+<parameters>
+	<parameter name="ip" unique="0" required="1" obsoletes="ipaddr">
+		<getopt mixed="-a, --ip=[ip]" />
+		<content type="string" />
+	</parameter>
+	<parameter name="ipaddr" unique="0" required="1" deprecated="1">
+		<getopt mixed="-a, --ip=[ip]" />
+		<content type="string" />
+	</parameter>
+	<parameter name="ipport" unique="0" required="0">
+		<getopt mixed="-u, --ipport=[port]" />
+		<content type="integer" default="623" />
+	</parameter>
+	<parameter name="lanplus" unique="0" required="0">
+		<getopt mixed="-P, --lanplus" />
+		<content type="boolean" />
+	</parameter>
+	<parameter name="method" unique="0" required="0">
+		<getopt mixed="-m, --method=[method]" />
+		<content type="select" default="onoff">
+			<option value="onoff" />
+			<option value="cycle" />
+		</content>
+	</parameter>
+	<parameter name="power_wait" unique="0" required="0">
+		<getopt mixed="--power-wait=[seconds]" />
+		<content type="second" default="2" />
+	</parameter>
+</parameters>
+</resource-agent>`
+
+func Test_validateAgentParameters(t *testing.T) {
+	validator := NewCustomAgentValidator(func(string) (bool, error) { return true, nil }, func(string) ([]byte, error) {
+		return []byte(testAgentMetadata), nil
+	})
+	tests := []struct {
+		name         string
+		params       map[string][]string
+		wantErr      bool
+		wantWarnings int
+	}{
+		//valid use-cases
+		{name: "longOptions", params: map[string][]string{"--ip": {"192.168.111.1"}, "--ipport": {"6233", "6234"}, "--lanplus": {""}}},
+		{name: "shortOptions", params: map[string][]string{"-a": {"192.168.111.1"}, "-m": {"cycle"}}},
+		{name: "stdinNames", params: map[string][]string{"ip": {"192.168.111.1"}, "power_wait": {"2.5"}, "lanplus": {"1"}}},
+		{name: "deprecatedName", params: map[string][]string{"ipaddr": {"192.168.111.1"}}, wantWarnings: 1},
+		{name: "missingRequired", params: map[string][]string{"--ipport": {"6233"}}, wantWarnings: 1},
+
+		//invalid use-cases
+		{name: "unknownParam", params: map[string][]string{"--ip": {"192.168.111.1"}, "--ip-port": {"6233"}}, wantErr: true},
+		{name: "notInteger", params: map[string][]string{"--ip": {"192.168.111.1"}, "--ipport": {"6233", "port"}}, wantErr: true},
+		{name: "notSeconds", params: map[string][]string{"--ip": {"192.168.111.1"}, "--power-wait": {"2s"}}, wantErr: true},
+		{name: "notAllowed", params: map[string][]string{"--ip": {"192.168.111.1"}, "--method": {"reset"}}, wantErr: true},
+		{name: "notBoolean", params: map[string][]string{"--ip": {"192.168.111.1"}, "--lanplus": {"maybe"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validator.ValidateAgentParameters("fence_ipmilan", tt.params)
+			if (err != nil) != tt.wantErr || len(warnings) != tt.wantWarnings {
+				t.Errorf("ValidateAgentParameters() error = %v, wantErr %v, warnings = %v, want %d warnings", err, tt.wantErr, warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_validateAgentParametersHidesValues(t *testing.T) {
+	validator := NewCustomAgentValidator(func(string) (bool, error) { return true, nil }, func(string) ([]byte, error) {
+		return []byte(testAgentMetadata), nil
+	})
+	// the values might come from a Secret, hence they must not be leaked by the errors
+	secretValue := "top-secret-value"
+	for _, paramName := range []string{"--ipport", "--power-wait", "--method", "--lanplus"} {
+		_, err := validator.ValidateAgentParameters("fence_ipmilan", map[string][]string{"--ip": {"192.168.111.1"}, paramName: {secretValue}})
+		if err == nil || strings.Contains(err.Error(), secretValue) {
+			t.Errorf("ValidateAgentParameters() of parameter %s error = %v, want an error without the value", paramName, err)
+		}
+	}
+}
+
+func Test_agentNameOutsideAgentsDirectory(t *testing.T) {
+	// the agent name comes from the CR, hence it must not lead to a binary outside the agents directory
+	for _, agent := range []string{"../../tmp/fence_x", "/tmp/fence_x", "fence_x/../../../tmp/fence_y", ""} {
+		if _, err := GetAgentMetadata(agent); err == nil || !strings.Contains(err.Error(), "invalid fence agent name") {
+			t.Errorf("GetAgentMetadata(%q) error = %v, want an invalid fence agent name error", agent, err)
+		}
+		if exists, err := isAgentFileExists(agent); exists || err != nil {
+			t.Errorf("isAgentFileExists(%q) = %v, %v, want false", agent, exists, err)
+		}
+	}
+}